@@ -0,0 +1,350 @@
+package client
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/handler"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
+	jsoniter "github.com/json-iterator/go"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Verifies tokens and API keys through the HTTP API of a running auth service, for services without a Mongo connection.
+// Successful sessions are cached for a minute at most and never past the token expiry, "cache-control: no-cache" skips the cache
+type RemoteVerifier struct {
+	BaseURL    string
+	HTTPClient *http.Client
+
+	mutex    sync.Mutex
+	sessions map[string]cachedSession
+}
+
+type cachedSession struct {
+	session *handler.Session
+	until   time.Time
+}
+
+type studentVerifyResponse struct {
+	Data          *model.StudentPopulated `json:"data"`
+	Roles         []string                `json:"roles"`
+	Error         *string                 `json:"error"`
+	Expire        *time.Time              `json:"expire"`
+	Impersonation *model.Impersonation    `json:"impersonation"`
+	RealStudent   *model.StudentPopulated `json:"realStudent"`
+	RealRoles     []string                `json:"realRoles"`
+}
+
+type recruiterVerifyResponse struct {
+	Data  *model.RecruiterModelPopulated `json:"data"`
+	Roles []string                       `json:"roles"`
+	Error *string                        `json:"error"`
+}
+
+// Principals whose role set can be filled from a verify response
+type resolvablePrincipal interface {
+	ResolveRoleSet()
+	SetResolvedRoles(roles []string)
+}
+
+// The auth service sends the roles expanded with its own role graph, which the local graph knows nothing about.
+// Only responses of older services without roles fall back to the local graph
+func resolveRoles(principal resolvablePrincipal, roles []string) {
+	if roles == nil {
+		principal.ResolveRoleSet()
+		return
+	}
+	principal.SetResolvedRoles(roles)
+}
+
+type permissionsResponse struct {
+	Data  *interfaces.PermissionsResponse `json:"data"`
+	Error *string                         `json:"error"`
+}
+
+func NewRemoteVerifier(baseURL string, httpClient *http.Client) *RemoteVerifier {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: constants.REMOTE_VERIFY_TIMEOUT}
+	}
+	return &RemoteVerifier{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: httpClient,
+		sessions:   map[string]cachedSession{},
+	}
+}
+
+// Drop-in for handler.NewAuthClient. The middlewares and role checkers of the Handler stay the same,
+// only the verification goes to the auth service at baseURL
+func NewRemoteAuthClient(baseURL string) *handler.Handler {
+	return &handler.Handler{
+		Verifier: NewRemoteVerifier(baseURL, nil),
+		Config: handler.Config{
+			Mode: handler.REMOTE,
+		},
+	}
+}
+
+func failedSession(err *string, status int, expire *time.Time) *handler.Session {
+	return &handler.Session{
+		Error:     errors.New(*err),
+		ErrorCode: err,
+		Status:    status,
+		Expire:    expire,
+	}
+}
+
+// The auth service has checked the signature already, the claims are only read for exp and auth_time
+func readClaims(idToken string) *interfaces.Token {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil
+	}
+
+	token := &interfaces.Token{}
+	if err := json.Unmarshal(payload, token); err != nil {
+		return nil
+	}
+	token.TokenID = token.Jti
+	return token
+}
+
+func getExpiry(token *interfaces.Token) *time.Time {
+	if token == nil || token.Exp == 0 {
+		return nil
+	}
+	expire := time.Unix(int64(token.Exp), 0)
+	return &expire
+}
+
+func (verifier *RemoteVerifier) get(path string, headers map[string]string, noCache bool, response interface{}) (int, error) {
+	request, err := http.NewRequest(http.MethodGet, verifier.BaseURL+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	for key, value := range headers {
+		if value != "" {
+			request.Header.Set(key, value)
+		}
+	}
+	if noCache {
+		request.Header.Set(constants.CACHE_CONTROL_HEADER, constants.NO_CACHE)
+	}
+
+	result, err := verifier.HTTPClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer result.Body.Close()
+
+	return result.StatusCode, json.NewDecoder(result.Body).Decode(response)
+}
+
+// Every request gets its own copy so that middlewares never share a Session
+func (verifier *RemoteVerifier) load(key string) *handler.Session {
+	verifier.mutex.Lock()
+	defer verifier.mutex.Unlock()
+
+	cached, found := verifier.sessions[key]
+	if !found {
+		return nil
+	}
+	if time.Now().After(cached.until) {
+		delete(verifier.sessions, key)
+		return nil
+	}
+	session := *cached.session
+	return &session
+}
+
+// Sessions are cached until the token expires but no longer than a minute, so that revocations, role
+// changes and deactivations on the auth service are seen soon after
+func cacheUntil(expire *time.Time) *time.Time {
+	until := time.Now().Add(constants.REMOTE_SESSION_CACHE_DURATION)
+	if expire != nil && expire.Before(until) {
+		return expire
+	}
+	return &until
+}
+
+// Caches the session until the given time and returns a copy of it for the current request
+func (verifier *RemoteVerifier) store(key string, session *handler.Session, until *time.Time) *handler.Session {
+	copied := *session
+	if until == nil || time.Now().After(*until) {
+		return &copied
+	}
+	verifier.mutex.Lock()
+	defer verifier.mutex.Unlock()
+
+	if len(verifier.sessions) >= constants.REMOTE_VERIFY_CACHE_SIZE {
+		now := time.Now()
+		for cachedKey, cached := range verifier.sessions {
+			if now.After(cached.until) {
+				delete(verifier.sessions, cachedKey)
+			}
+		}
+		if len(verifier.sessions) >= constants.REMOTE_VERIFY_CACHE_SIZE {
+			verifier.sessions = map[string]cachedSession{}
+		}
+	}
+	verifier.sessions[key] = cachedSession{session: session, until: *until}
+	return &copied
+}
+
+// Drops every cached session, for example after revoking tokens
+func (verifier *RemoteVerifier) InvalidateCache() {
+	verifier.mutex.Lock()
+	defer verifier.mutex.Unlock()
+	verifier.sessions = map[string]cachedSession{}
+}
+
+// Impersonated sessions are never cached so that the auth service logs every use
+func (verifier *RemoteVerifier) VerifyStudentSession(idToken string, impersonateId string, method string, path string, noCache bool) *handler.Session {
+	key := "student:" + idToken
+	if impersonateId == "" && !noCache {
+		if session := verifier.load(key); session != nil {
+			return session
+		}
+	}
+
+	response := studentVerifyResponse{}
+	status, err := verifier.get("/api/token/student/verify", map[string]string{
+		"token":                      idToken,
+		constants.IMPERSONATE_HEADER: impersonateId,
+	}, noCache, &response)
+	if err != nil || (status != http.StatusOK && status != http.StatusForbidden) {
+		return failedSession(&constants.ERROR_REMOTE_VERIFICATION, http.StatusBadGateway, nil)
+	}
+	if response.Data == nil {
+		errorCode := response.Error
+		if errorCode == nil {
+			errorCode = &constants.ERROR_REMOTE_VERIFICATION
+		}
+		if status == http.StatusForbidden {
+			return failedSession(errorCode, http.StatusForbidden, response.Expire)
+		}
+		return failedSession(errorCode, http.StatusUnauthorized, response.Expire)
+	}
+
+	// The auth service is always asked with GET, so read-only impersonations are checked here
+	if response.Impersonation != nil {
+		if err := controller.CheckImpersonationMethod(response.Impersonation, method); err != nil {
+			return failedSession(err, http.StatusForbidden, response.Expire)
+		}
+	}
+
+	resolveRoles(response.Data, response.Roles)
+	if response.RealStudent != nil {
+		resolveRoles(response.RealStudent, response.RealRoles)
+	}
+
+	token := readClaims(idToken)
+	session := &handler.Session{
+		Principal:     response.Data,
+		Student:       response.Data,
+		Token:         token,
+		Expire:        response.Expire,
+		Impersonation: response.Impersonation,
+		RealStudent:   response.RealStudent,
+	}
+	if session.Expire == nil {
+		session.Expire = getExpiry(token)
+	}
+	if impersonateId != "" {
+		return session
+	}
+
+	return verifier.store(key, session, cacheUntil(session.Expire))
+}
+
+// API keys are checked with the permissions of the key and cached for a short while, as they have no expiry of their own
+func (verifier *RemoteVerifier) VerifyPrincipalSession(authorization string, idToken string, impersonateId string, method string, path string, noCache bool) *handler.Session {
+	if _, isAPIKey := util.GetAPIKey(authorization); !isAPIKey {
+		return verifier.VerifyStudentSession(idToken, impersonateId, method, path, noCache)
+	}
+
+	key := "apikey:" + authorization
+	if !noCache {
+		if session := verifier.load(key); session != nil {
+			return session
+		}
+	}
+
+	response := permissionsResponse{}
+	status, err := verifier.get("/api/me/permissions", map[string]string{
+		"Authorization": authorization,
+	}, noCache, &response)
+	if err != nil || status >= http.StatusInternalServerError {
+		return failedSession(&constants.ERROR_REMOTE_VERIFICATION, http.StatusBadGateway, nil)
+	}
+	if status != http.StatusOK || response.Data == nil || response.Data.Kind != model.PRINCIPAL_API_CLIENT {
+		errorCode := response.Error
+		if errorCode == nil {
+			errorCode = &constants.ERROR_INVALID_API_KEY
+		}
+		return failedSession(errorCode, http.StatusUnauthorized, nil)
+	}
+
+	// The roles come expanded by the auth service
+	apiKey := &model.APIKey{
+		Id:    response.Data.Id,
+		Roles: response.Data.Roles,
+	}
+	apiKey.SetResolvedRoles(response.Data.Roles)
+	session := &handler.Session{
+		Principal: apiKey,
+	}
+	until := time.Now().Add(constants.REMOTE_API_KEY_CACHE_DURATION)
+	return verifier.store(key, session, &until)
+}
+
+func (verifier *RemoteVerifier) VerifyRecruiterSession(idToken string, noCache bool) *handler.Session {
+	key := "recruiter:" + idToken
+	if !noCache {
+		if session := verifier.load(key); session != nil {
+			return session
+		}
+	}
+
+	response := recruiterVerifyResponse{}
+	status, err := verifier.get("/api/token/verify", map[string]string{
+		"token": idToken,
+	}, noCache, &response)
+	if err != nil || (status >= http.StatusInternalServerError && response.Error == nil) {
+		return failedSession(&constants.ERROR_REMOTE_VERIFICATION, http.StatusBadGateway, nil)
+	}
+
+	token := readClaims(idToken)
+	expire := getExpiry(token)
+	if response.Data == nil {
+		errorCode := response.Error
+		if errorCode == nil {
+			errorCode = &constants.ERROR_REMOTE_VERIFICATION
+		}
+		// A verified token without an active recruiter is answered with 200
+		if status == http.StatusOK {
+			return failedSession(errorCode, http.StatusForbidden, expire)
+		}
+		return failedSession(errorCode, http.StatusUnauthorized, expire)
+	}
+
+	resolveRoles(response.Data, response.Roles)
+	session := &handler.Session{
+		Principal: response.Data,
+		Token:     token,
+		Expire:    expire,
+	}
+	return verifier.store(key, session, cacheUntil(expire))
+}
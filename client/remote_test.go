@@ -0,0 +1,257 @@
+package client
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/model"
+)
+
+// A token the auth service would accept. Only the payload is read on this side
+func testToken(name string, expire time.Time) string {
+	payload := `{"sub": "` + name + `", "exp": ` + strconv.FormatInt(expire.Unix(), 10) + `}`
+	return "header." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + name
+}
+
+// Answers every verify endpoint with the status and body registered for the token or API key, and counts the calls
+type fakeAuthService struct {
+	mutex     sync.Mutex
+	responses map[string]fakeResponse
+	calls     int
+}
+
+type fakeResponse struct {
+	status int
+	body   string
+}
+
+func (service *fakeAuthService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	service.mutex.Lock()
+	service.calls++
+	response, found := service.responses[r.URL.Path+" "+r.Header.Get("token")+r.Header.Get("Authorization")]
+	service.mutex.Unlock()
+
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(response.status)
+	w.Write([]byte(response.body))
+}
+
+func (service *fakeAuthService) callCount() int {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	return service.calls
+}
+
+func newTestRemoteVerifier(t *testing.T, responses map[string]fakeResponse) (*RemoteVerifier, *fakeAuthService) {
+	t.Helper()
+	service := &fakeAuthService{responses: responses}
+	server := httptest.NewServer(service)
+	t.Cleanup(server.Close)
+	return NewRemoteVerifier(server.URL, server.Client()), service
+}
+
+func TestRemoteVerifyStudentSession(t *testing.T) {
+	expire := time.Now().Add(time.Hour)
+	student := testToken("student", expire)
+	readOnly := testToken("read-only", expire)
+	verifier, _ := newTestRemoteVerifier(t, map[string]fakeResponse{
+		"/api/token/student/verify " + student:                   {http.StatusOK, `{"data": {"firstName": "Student"}, "roles": ["STUDENT", "CUSTOM_ROLE"]}`},
+		"/api/token/student/verify " + readOnly:                  {http.StatusOK, `{"data": {}, "roles": ["STUDENT"], "impersonation": {"readOnly": true}}`},
+		"/api/token/student/verify invalid":                      {http.StatusOK, `{"data": null, "error": "ERROR_INVALID_TOKEN"}`},
+		"/api/token/student/verify revoked":                      {http.StatusOK, `{"data": null, "error": "ERROR_TOKEN_REVOKED", "expire": "2030-01-01T00:00:00Z"}`},
+		"/api/token/student/verify unauthorized-impersonation":   {http.StatusForbidden, `{"data": null, "error": "ERROR_UNAUTHORIZED_IMPERSONATION"}`},
+		"/api/token/student/verify forbidden-without-error-code": {http.StatusForbidden, `{}`},
+		"/api/token/student/verify down":                         {http.StatusInternalServerError, `{"data": null, "error": "ERROR_MONGO_ERROR"}`},
+		"/api/token/student/verify garbage":                      {http.StatusOK, `not json`},
+	})
+
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		status int
+		err    string
+	}{
+		{"student", student, http.MethodPost, 0, ""},
+		{"read-only impersonation of a read", readOnly, http.MethodGet, 0, ""},
+		{"read-only impersonation of a write", readOnly, http.MethodPost, http.StatusForbidden, constants.ERROR_IMPERSONATION_READ_ONLY},
+		{"invalid token", "invalid", http.MethodGet, http.StatusUnauthorized, constants.ERROR_INVALID_TOKEN},
+		{"revoked token", "revoked", http.MethodGet, http.StatusUnauthorized, constants.ERROR_TOKEN_REVOKED},
+		{"impersonation refused", "unauthorized-impersonation", http.MethodGet, http.StatusForbidden, constants.ERROR_UNAUTHORIZED_IMPERSONATION},
+		{"refused without an error code", "forbidden-without-error-code", http.MethodGet, http.StatusForbidden, constants.ERROR_REMOTE_VERIFICATION},
+		{"auth service failing", "down", http.MethodGet, http.StatusBadGateway, constants.ERROR_REMOTE_VERIFICATION},
+		{"unknown endpoint", "unknown", http.MethodGet, http.StatusBadGateway, constants.ERROR_REMOTE_VERIFICATION},
+		{"invalid response", "garbage", http.MethodGet, http.StatusBadGateway, constants.ERROR_REMOTE_VERIFICATION},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			session := verifier.VerifyStudentSession(test.token, "", test.method, "/api/student", true)
+			if test.err != "" {
+				if session.Error == nil || *session.ErrorCode != test.err || session.Status != test.status {
+					t.Errorf("VerifyStudentSession() = %v %d, want %s %d", session.Error, session.Status, test.err, test.status)
+				}
+				return
+			}
+			if session.Error != nil {
+				t.Fatalf("VerifyStudentSession() error = %v", session.Error)
+			}
+			if session.Student == nil || session.Principal.PrincipalKind() != model.PRINCIPAL_STUDENT {
+				t.Fatalf("VerifyStudentSession() = %+v, want a student", session)
+			}
+			if session.Expire == nil || session.Expire.Unix() != expire.Unix() {
+				t.Errorf("Expire = %v, want the exp of the token %v", session.Expire, expire)
+			}
+		})
+	}
+
+	// The roles come from the auth service, even those the local role graph does not know
+	session := verifier.VerifyStudentSession(student, "", http.MethodGet, "/api/student", true)
+	if !session.Principal.RoleSet().Has("CUSTOM_ROLE") {
+		t.Errorf("roles = %v, want the roles sent by the auth service", session.Principal.RoleSet().List())
+	}
+}
+
+func TestRemoteVerifyRecruiterSession(t *testing.T) {
+	recruiter := testToken("recruiter", time.Now().Add(time.Hour))
+	verifier, _ := newTestRemoteVerifier(t, map[string]fakeResponse{
+		"/api/token/verify " + recruiter: {http.StatusOK, `{"data": {"email": "hr@example.com"}, "roles": ["recruiter"]}`},
+		"/api/token/verify inactive":     {http.StatusOK, `{"data": null, "error": "ERROR_RECRUITER_INACTIVE"}`},
+		"/api/token/verify invalid":      {http.StatusUnauthorized, `{"data": null, "error": "ERROR_INVALID_TOKEN"}`},
+		"/api/token/verify down":         {http.StatusServiceUnavailable, `{}`},
+	})
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+		err    string
+	}{
+		{"recruiter", recruiter, 0, ""},
+		{"verified token without an active recruiter", "inactive", http.StatusForbidden, constants.ERROR_RECRUITER_INACTIVE},
+		{"invalid token", "invalid", http.StatusUnauthorized, constants.ERROR_INVALID_TOKEN},
+		{"auth service failing", "down", http.StatusBadGateway, constants.ERROR_REMOTE_VERIFICATION},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			session := verifier.VerifyRecruiterSession(test.token, true)
+			if test.err != "" {
+				if session.Error == nil || *session.ErrorCode != test.err || session.Status != test.status {
+					t.Errorf("VerifyRecruiterSession() = %v %d, want %s %d", session.Error, session.Status, test.err, test.status)
+				}
+				return
+			}
+			if session.Error != nil {
+				t.Fatalf("VerifyRecruiterSession() error = %v", session.Error)
+			}
+			if session.Principal.PrincipalKind() != model.PRINCIPAL_RECRUITER || session.Principal.PrincipalEmail() != "hr@example.com" {
+				t.Errorf("VerifyRecruiterSession() = %+v, want the recruiter", session.Principal)
+			}
+		})
+	}
+}
+
+func TestRemoteVerifyPrincipalSession(t *testing.T) {
+	verifier, _ := newTestRemoteVerifier(t, map[string]fakeResponse{
+		"/api/me/permissions ApiKey frk_valid":   {http.StatusOK, `{"data": {"_id": "65f000000000000000000001", "kind": "api_client", "roles": ["GROUP_READ"]}}`},
+		"/api/me/permissions ApiKey frk_student": {http.StatusOK, `{"data": {"_id": "65f000000000000000000002", "kind": "student", "roles": ["STUDENT"]}}`},
+		"/api/me/permissions ApiKey frk_revoked": {http.StatusUnauthorized, `{"data": null, "error": "ERROR_API_KEY_REVOKED"}`},
+		"/api/me/permissions ApiKey frk_invalid": {http.StatusUnauthorized, `{}`},
+		"/api/me/permissions ApiKey frk_down":    {http.StatusInternalServerError, `{}`},
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		err           string
+	}{
+		{"api key", "ApiKey frk_valid", 0, ""},
+		{"answered as another principal", "ApiKey frk_student", http.StatusUnauthorized, constants.ERROR_INVALID_API_KEY},
+		{"revoked key", "ApiKey frk_revoked", http.StatusUnauthorized, constants.ERROR_API_KEY_REVOKED},
+		{"refused without an error code", "ApiKey frk_invalid", http.StatusUnauthorized, constants.ERROR_INVALID_API_KEY},
+		{"auth service failing", "ApiKey frk_down", http.StatusBadGateway, constants.ERROR_REMOTE_VERIFICATION},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			session := verifier.VerifyPrincipalSession(test.authorization, "", "", http.MethodGet, "/api/group", true)
+			if test.err != "" {
+				if session.Error == nil || *session.ErrorCode != test.err || session.Status != test.status {
+					t.Errorf("VerifyPrincipalSession() = %v %d, want %s %d", session.Error, session.Status, test.err, test.status)
+				}
+				return
+			}
+			if session.Error != nil {
+				t.Fatalf("VerifyPrincipalSession() error = %v", session.Error)
+			}
+			if session.Principal.PrincipalKind() != model.PRINCIPAL_API_CLIENT || !session.Principal.RoleSet().Has("GROUP_READ") {
+				t.Errorf("VerifyPrincipalSession() = %+v, want the API key with its roles", session.Principal)
+			}
+		})
+	}
+}
+
+func TestRemoteVerifierCache(t *testing.T) {
+	longLived := testToken("long-lived", time.Now().Add(24*time.Hour))
+	shortLived := testToken("short-lived", time.Now().Add(10*time.Second))
+	verifier, service := newTestRemoteVerifier(t, map[string]fakeResponse{
+		"/api/token/student/verify " + longLived:  {http.StatusOK, `{"data": {}, "roles": ["STUDENT"]}`},
+		"/api/token/student/verify " + shortLived: {http.StatusOK, `{"data": {}, "roles": ["STUDENT"]}`},
+		"/api/token/student/verify invalid":       {http.StatusOK, `{"data": null, "error": "ERROR_INVALID_TOKEN"}`},
+	})
+
+	tests := []struct {
+		name          string
+		token         string
+		impersonateId string
+		noCache       bool
+		calls         int
+	}{
+		{"first verification", longLived, "", false, 1},
+		{"served from the cache", longLived, "", false, 1},
+		{"no-cache asks again", longLived, "", true, 2},
+		{"impersonations are never cached", longLived, "65f000000000000000000001", false, 3},
+		{"failures are not cached", "invalid", "", false, 4},
+		{"failures are asked again", "invalid", "", false, 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier.VerifyStudentSession(test.token, test.impersonateId, http.MethodGet, "/api/student", test.noCache)
+			if calls := service.callCount(); calls != test.calls {
+				t.Errorf("the auth service was asked %d times, want %d", calls, test.calls)
+			}
+		})
+	}
+
+	// Cached for a minute at most, and never past the expiry of the token
+	verifier.VerifyStudentSession(shortLived, "", http.MethodGet, "/api/student", false)
+	now := time.Now()
+	verifier.mutex.Lock()
+	longUntil := verifier.sessions["student:"+longLived].until
+	shortUntil := verifier.sessions["student:"+shortLived].until
+	verifier.mutex.Unlock()
+	if longUntil.After(now.Add(constants.REMOTE_SESSION_CACHE_DURATION)) {
+		t.Errorf("long-lived session cached until %s, want at most %s", longUntil, constants.REMOTE_SESSION_CACHE_DURATION)
+	}
+	if shortUntil.After(now.Add(10 * time.Second)) {
+		t.Errorf("short-lived session cached until %s, past the expiry of its token", shortUntil)
+	}
+
+	verifier.InvalidateCache()
+	verifier.VerifyStudentSession(longLived, "", http.MethodGet, "/api/student", false)
+	if calls := service.callCount(); calls != 7 {
+		t.Errorf("the auth service was asked %d times after invalidating the cache, want 7", calls)
+	}
+}
@@ -16,12 +16,72 @@ const COLLECTION_ATTENDANCE = "attendances"
 const COLLECTION_RECRUITER = "recruiters"
 const COLLECTION_DOMAIN = "domains"
 const COLLECTION_COMPANY = "companies"
+const COLLECTION_SESSION = "sessions"
+const COLLECTION_API_KEY = "apikeys"
+const COLLECTION_IMPERSONATION = "impersonations"
+const COLLECTION_ROLE = "roles"
+const COLLECTION_MEMBERSHIP = "memberships"
+const COLLECTION_PENDING_CHANGE = "pendingchanges"
 
 const FIREBASE_PROJECT_ID = "FIREBASE_PROJECT_ID"
+const TRUSTED_ISSUERS_CONFIG = "TRUSTED_ISSUERS_CONFIG"
+const SESSION_SIGNING_KEY = "SESSION_SIGNING_KEY"
+const SESSION_ISSUER = "SESSION_ISSUER"
+const SESSION_AUDIENCE = "SESSION_AUDIENCE"
+const INTROSPECTION_CLIENTS = "INTROSPECTION_CLIENTS"
+const ROLE_GRAPH_CONFIG = "ROLE_GRAPH_CONFIG"
+const REQUIRE_EMAIL_VERIFIED = "REQUIRE_EMAIL_VERIFIED"
+const ALLOWED_SIGN_IN_PROVIDERS = "ALLOWED_SIGN_IN_PROVIDERS"
+const INSTITUTE_SIGN_IN_PROVIDERS = "INSTITUTE_SIGN_IN_PROVIDERS"
+const SENSITIVE_MAX_AUTH_AGE = "SENSITIVE_MAX_AUTH_AGE"
+const ROUTE_POLICY_CONFIG = "ROUTE_POLICY_CONFIG"
+const SENSITIVE_ROLES = "SENSITIVE_ROLES"
+
+const FIREBASE_ISSUER_PREFIX = "https://securetoken.google.com/"
+const FIREBASE_JWKS_URL = "https://www.googleapis.com/service_accounts/v1/jwk/securetoken@system.gserviceaccount.com"
+const DEFAULT_EMAIL_CLAIM = "email"
+
+const DEFAULT_SESSION_ISSUER = "frostik-auth"
+const SESSION_ACCESS_TOKEN_TTL = 15 * time.Minute
+const SESSION_REFRESH_TOKEN_TTL = 30 * 24 * time.Hour
+const TOKEN_USE_ACCESS = "access"
+const TOKEN_TYPE_BEARER = "Bearer"
+
+const REVOCATION_KEY_PREFIX = "auth:revocation:"
+const REVOCATION_RETENTION = 24 * time.Hour
+const REVOCATION_AUTH_TIME_RETENTION = 30 * 24 * time.Hour
+
+const DEFAULT_SENSITIVE_MAX_AUTH_AGE = 30 * time.Minute
+
+const API_KEY_PREFIX = "frk"
+const API_KEY_AUTHORIZATION_SCHEME = "ApiKey"
+const API_KEY_LAST_USED_INTERVAL = time.Minute
+
+const DEFAULT_IMPERSONATION_DURATION = 30 * time.Minute
+const MAX_IMPERSONATION_DURATION = 4 * time.Hour
+
+const ROLE_GRAPH_REFRESH_INTERVAL = 5 * time.Minute
+const MEMBERSHIP_SWEEP_INTERVAL = 10 * time.Minute
+
+const PENDING_CHANGE_TTL = 48 * time.Hour
+
+// Relative to the working directory the service is started from
+const DEFAULT_ROUTE_POLICY_CONFIG = "policy.json"
+const ROUTE_POLICY_REFRESH_INTERVAL = 30 * time.Second
 
 const CACHING_DURATION = 20 * time.Hour
 const CACHE_CONTROL_HEADER = "cache-control"
 const NO_CACHE = "no-cache"
 
-// Keys of Cache
-const GCP_JWKS = "GCP_JWKS"
+// Refresh of JWKs kept in memory
+const JWKS_DEFAULT_MAX_AGE = time.Hour
+const JWKS_MIN_REFRESH_INTERVAL = time.Minute
+const JWKS_MAX_REFRESH_INTERVAL = 24 * time.Hour
+const JWKS_KID_MISS_REFETCH_INTERVAL = 30 * time.Second
+const JWKS_FETCH_TIMEOUT = 10 * time.Second
+
+// Remote verification against the HTTP API of the auth service
+const REMOTE_VERIFY_TIMEOUT = 10 * time.Second
+const REMOTE_API_KEY_CACHE_DURATION = time.Minute
+const REMOTE_SESSION_CACHE_DURATION = time.Minute
+const REMOTE_VERIFY_CACHE_SIZE = 10000
@@ -9,9 +9,50 @@ var ERROR_PARSING_JWK string = "ERROR_PARSING_JWK"
 var ERROR_TOKEN_SIGNATURE_INVALID string = "ERROR_TOKEN_SIGNATURE_INVALID"
 var ERROR_GETTING_EMAIL string = "ERROR_GETTING_EMAIL"
 var ERROR_INVALID_TOKEN string = "ERROR_INVALID_TOKEN"
+var ERROR_UNTRUSTED_ISSUER string = "ERROR_UNTRUSTED_ISSUER"
+var ERROR_FETCH_DISCOVERY string = "ERROR_FETCH_DISCOVERY"
+var ERROR_REMOTE_VERIFICATION string = "ERROR_REMOTE_VERIFICATION"
+var ERROR_SESSIONS_DISABLED string = "ERROR_SESSIONS_DISABLED"
+var ERROR_SIGNING_SESSION_TOKEN string = "ERROR_SIGNING_SESSION_TOKEN"
+var ERROR_CREATING_SESSION string = "ERROR_CREATING_SESSION"
+var ERROR_INVALID_REFRESH_TOKEN string = "ERROR_INVALID_REFRESH_TOKEN"
+var ERROR_REFRESH_TOKEN_REUSED string = "ERROR_REFRESH_TOKEN_REUSED"
+var ERROR_TOKEN_REVOKED string = "ERROR_TOKEN_REVOKED"
+var ERROR_INVALID_REVOCATION string = "ERROR_INVALID_REVOCATION"
+var ERROR_STORING_REVOCATION string = "ERROR_STORING_REVOCATION"
+var ERROR_CHECKING_REVOCATION string = "ERROR_CHECKING_REVOCATION"
+var ERROR_INVALID_CLIENT string = "invalid_client"
+var ERROR_INVALID_REQUEST string = "invalid_request"
+var ERROR_EMAIL_NOT_VERIFIED string = "ERROR_EMAIL_NOT_VERIFIED"
+var ERROR_SIGN_IN_PROVIDER_NOT_ALLOWED string = "ERROR_SIGN_IN_PROVIDER_NOT_ALLOWED"
+var ERROR_AUTH_TIME_MISSING string = "ERROR_AUTH_TIME_MISSING"
+var ERROR_REAUTHENTICATION_REQUIRED string = "ERROR_REAUTHENTICATION_REQUIRED"
+var ERROR_INVALID_API_KEY string = "ERROR_INVALID_API_KEY"
+var ERROR_API_KEY_EXPIRED string = "ERROR_API_KEY_EXPIRED"
+var ERROR_API_KEY_REVOKED string = "ERROR_API_KEY_REVOKED"
+var ERROR_UNKNOWN_ROLE string = "ERROR_UNKNOWN_ROLE"
+var ERROR_INVALID_MEMBERSHIP_WINDOW string = "ERROR_INVALID_MEMBERSHIP_WINDOW"
+var ERROR_RESOLVING_MEMBERSHIPS string = "ERROR_RESOLVING_MEMBERSHIPS"
+var ERROR_STORING_API_KEY string = "ERROR_STORING_API_KEY"
+var ERROR_API_KEY_SENSITIVE_ROLE string = "ERROR_API_KEY_SENSITIVE_ROLE"
+var ERROR_UNAUTHORIZED_IMPERSONATION string = "ERROR_UNAUTHORIZED_IMPERSONATION"
+var ERROR_NO_ACTIVE_IMPERSONATION string = "ERROR_NO_ACTIVE_IMPERSONATION"
+var ERROR_IMPERSONATION_READ_ONLY string = "ERROR_IMPERSONATION_READ_ONLY"
+var ERROR_INVALID_IMPERSONATION string = "ERROR_INVALID_IMPERSONATION"
+var ERROR_IMPERSONATION_PRIVILEGED_TARGET string = "ERROR_IMPERSONATION_PRIVILEGED_TARGET"
+var ERROR_STORING_IMPERSONATION string = "ERROR_STORING_IMPERSONATION"
 var ERROR_FAILED_FETCH_FROM_DB string = "ERROR_FAILED_FETCH_FROM_DB"
 
 var ERROR_NOT_A_STUDENT string = "ERROR_NOT_A_STUDENT"
 var ERROR_NOT_A_RECRUITER string = "ERROR_NOT_A_RECRUITER"
+var ERROR_RECRUITER_INACTIVE string = "ERROR_RECRUITER_INACTIVE"
 
 var ERROR_ROLE_CHECK_FAILED string = "ERROR_ROLE_CHECKED_FAILED"
+var ERROR_TARGET_OUT_OF_SCOPE string = "ERROR_TARGET_OUT_OF_SCOPE"
+var ERROR_NO_ROUTE_POLICY string = "ERROR_NO_ROUTE_POLICY"
+
+var ERROR_PENDING_CHANGE_NOT_FOUND string = "ERROR_PENDING_CHANGE_NOT_FOUND"
+var ERROR_PENDING_CHANGE_EXPIRED string = "ERROR_PENDING_CHANGE_EXPIRED"
+var ERROR_SELF_APPROVAL string = "ERROR_SELF_APPROVAL"
+var ERROR_APPROVAL_WHILE_IMPERSONATING string = "ERROR_APPROVAL_WHILE_IMPERSONATING"
+var ERROR_STORING_PENDING_CHANGE string = "ERROR_STORING_PENDING_CHANGE"
@@ -1,3 +1,9 @@
 package constants
 
-const SESSION = "SESSION"
\ No newline at end of file
+const SESSION = "SESSION"
+const AUTH_SESSION = "AUTH_SESSION"
+const TOKEN = "TOKEN"
+const REAL_PRINCIPAL = "REAL_PRINCIPAL"
+const IMPERSONATION = "IMPERSONATION"
+const IMPERSONATE_HEADER = "x-impersonate-student-id"
+const ROLE_EXPRESSION = "ROLE_EXPRESSION"
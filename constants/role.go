@@ -25,6 +25,57 @@ var ROLE_COMPANY_ALL_READ = "COMPANY_ALL_READ"
 
 var ROLE_STUDENT_VERIFY = "STUDENT_VERIFY"
 
+// Built-in roles, the catalog adds the ones named in ROLE_GRAPH_CONFIG and the roles collection
+var ALL_ROLES = []string{
+	ROLE_TPR, ROLE_STUDENT, ROLE_RECRUITER, ROLE_ADMIN,
+	ROLE_GROUP_READ, ROLE_GROUP_EDIT, ROLE_GROUP_CREATE, ROLE_GROUP_DELETE, ROLE_GROUP_ASSIGN,
+	ROLE_OPPORTUNITIES_READ, ROLE_OPPORTUNITIES_WRITE, ROLE_OPPORTUNITIES_EDIT, ROLE_OPPORTUNITIES_DELETE,
+	ROLE_DOMAIN_ALL_READ, ROLE_DOMAIN_CREATE, ROLE_DOMAIN_EDIT, ROLE_DOMAIN_DELETE,
+	ROLE_COMPANY_ALL_READ,
+	ROLE_STUDENT_VERIFY,
+}
+
+// Implies every known role
+const ROLE_WILDCARD = "*"
+
+// Built-in implications, extended by ROLE_GRAPH_CONFIG and the roles collection
+var DEFAULT_ROLE_IMPLICATIONS = map[string][]string{
+	ROLE_ADMIN:                {ROLE_WILDCARD},
+	ROLE_GROUP_EDIT:           {ROLE_GROUP_READ},
+	ROLE_GROUP_CREATE:         {ROLE_GROUP_READ},
+	ROLE_GROUP_DELETE:         {ROLE_GROUP_READ},
+	ROLE_GROUP_ASSIGN:         {ROLE_GROUP_READ},
+	ROLE_OPPORTUNITIES_WRITE:  {ROLE_OPPORTUNITIES_READ},
+	ROLE_OPPORTUNITIES_EDIT:   {ROLE_OPPORTUNITIES_READ},
+	ROLE_OPPORTUNITIES_DELETE: {ROLE_OPPORTUNITIES_READ},
+	ROLE_DOMAIN_CREATE:        {ROLE_DOMAIN_ALL_READ},
+	ROLE_DOMAIN_EDIT:          {ROLE_DOMAIN_ALL_READ},
+	ROLE_DOMAIN_DELETE:        {ROLE_DOMAIN_ALL_READ},
+}
+
+// Shown in the role catalog, roles from the roles collection bring their own description
+var ROLE_DESCRIPTIONS = map[string]string{
+	ROLE_TPR:                  "Training and placement representative",
+	ROLE_STUDENT:              "Registered student",
+	ROLE_RECRUITER:            "Recruiter of a registered company",
+	ROLE_ADMIN:                "Full access, implies every other role",
+	ROLE_GROUP_READ:           "List groups and their roles",
+	ROLE_GROUP_EDIT:           "Add or remove roles and scopes of groups",
+	ROLE_GROUP_CREATE:         "Create groups",
+	ROLE_GROUP_DELETE:         "Delete groups",
+	ROLE_GROUP_ASSIGN:         "Add students to or remove them from groups",
+	ROLE_OPPORTUNITIES_READ:   "View opportunities",
+	ROLE_OPPORTUNITIES_WRITE:  "Manage opportunities and view student records",
+	ROLE_OPPORTUNITIES_EDIT:   "Edit opportunities",
+	ROLE_OPPORTUNITIES_DELETE: "Delete opportunities",
+	ROLE_DOMAIN_ALL_READ:      "View domains",
+	ROLE_DOMAIN_CREATE:        "Create domains",
+	ROLE_DOMAIN_EDIT:          "Edit domains",
+	ROLE_DOMAIN_DELETE:        "Delete domains",
+	ROLE_COMPANY_ALL_READ:     "View companies",
+	ROLE_STUDENT_VERIFY:       "Verify student profiles",
+}
+
 var ENV_STUDENT_GROUP_OBJ_ID = "STUDENT_GROUP_OBJ_ID"
 
 type Action string
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/model"
+	db "github.com/FrosTiK-SD/mongik/db"
+	models "github.com/FrosTiK-SD/mongik/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func hashAPIKeySecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// Keys look like frk_<key id>_<secret> so the document can be found without scanning every hash
+func newAPIKeySecret(keyId primitive.ObjectID) (string, string, *string) {
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", &constants.ERROR_INVALID_API_KEY
+	}
+	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	return fmt.Sprintf("%s_%s_%s", constants.API_KEY_PREFIX, keyId.Hex(), secret), hashAPIKeySecret(secret), nil
+}
+
+func CreateAPIKey(mongikClient *models.Mongik, name string, owner primitive.ObjectID, roles []string, expiresAt *primitive.DateTime) (string, *model.APIKey, *string) {
+	if err := ValidateRoles(roles); err != nil {
+		return "", nil, err
+	}
+	// A long-lived secret has no recent sign-in or second admin to check, so it never carries sensitive roles
+	if IsSensitive(roles) {
+		return "", nil, &constants.ERROR_API_KEY_SENSITIVE_ROLE
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	apiKey := model.APIKey{
+		Id:        primitive.NewObjectID(),
+		Name:      name,
+		Owner:     owner,
+		Roles:     roles,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	key, keyHash, err := newAPIKeySecret(apiKey.Id)
+	if err != nil {
+		return "", nil, err
+	}
+	apiKey.KeyHash = keyHash
+
+	if _, err := db.InsertOne(mongikClient, constants.DB, constants.COLLECTION_API_KEY, apiKey); err != nil {
+		return "", nil, &constants.ERROR_STORING_API_KEY
+	}
+
+	return key, &apiKey, nil
+}
+
+// Issues a new secret for the key, the old one stops working right away
+func RotateAPIKey(mongikClient *models.Mongik, keyId primitive.ObjectID) (string, *model.APIKey, *string) {
+	key, keyHash, err := newAPIKeySecret(keyId)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if _, updateErr := db.UpdateMany[model.APIKey](mongikClient, constants.DB, constants.COLLECTION_API_KEY, bson.M{
+		"_id":     keyId,
+		"revoked": false,
+	}, bson.M{
+		"$set": bson.M{
+			"keyHash":   keyHash,
+			"updatedAt": primitive.NewDateTimeFromTime(time.Now()),
+		},
+	}); updateErr != nil {
+		return "", nil, &constants.ERROR_STORING_API_KEY
+	}
+
+	apiKey, getErr := getAPIKey(mongikClient, keyId)
+	if getErr != nil || apiKey.Revoked || apiKey.KeyHash != keyHash {
+		return "", nil, &constants.ERROR_INVALID_API_KEY
+	}
+
+	return key, apiKey, nil
+}
+
+func RevokeAPIKey(mongikClient *models.Mongik, keyId primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return db.UpdateMany[model.APIKey](mongikClient, constants.DB, constants.COLLECTION_API_KEY, bson.M{
+		"_id": keyId,
+	}, bson.M{
+		"$set": bson.M{
+			"revoked":   true,
+			"updatedAt": primitive.NewDateTimeFromTime(time.Now()),
+		},
+	})
+}
+
+func GetAllAPIKeys(mongikClient *models.Mongik, noCache bool) (*[]model.APIKey, error) {
+	apiKeys, err := db.Aggregate[model.APIKey](mongikClient, constants.DB, constants.COLLECTION_API_KEY, []bson.M{{
+		"$sort": bson.M{"createdAt": -1},
+	}}, noCache)
+
+	return &apiKeys, err
+}
+
+// Reads straight from Mongo so that a revoked or rotated key is never served from the cache
+func getAPIKey(mongikClient *models.Mongik, keyId primitive.ObjectID) (*model.APIKey, error) {
+	var apiKey model.APIKey
+	apiKeyCollection := mongikClient.MongoClient.Database(constants.DB).Collection(constants.COLLECTION_API_KEY)
+	if err := apiKeyCollection.FindOne(context.Background(), bson.M{"_id": keyId}).Decode(&apiKey); err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// Splits frk_<key id>_<secret> into the key id and the secret
+func parseAPIKey(key string) (primitive.ObjectID, string, *string) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != constants.API_KEY_PREFIX || parts[2] == "" {
+		return primitive.NilObjectID, "", &constants.ERROR_INVALID_API_KEY
+	}
+	keyId, err := primitive.ObjectIDFromHex(parts[1])
+	if err != nil {
+		return primitive.NilObjectID, "", &constants.ERROR_INVALID_API_KEY
+	}
+	return keyId, parts[2], nil
+}
+
+func checkAPIKey(apiKey *model.APIKey, secret string) *string {
+	if subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(hashAPIKeySecret(secret))) != 1 {
+		return &constants.ERROR_INVALID_API_KEY
+	}
+	if apiKey.Revoked {
+		return &constants.ERROR_API_KEY_REVOKED
+	}
+	if apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Time().Before(time.Now()) {
+		return &constants.ERROR_API_KEY_EXPIRED
+	}
+	return nil
+}
+
+func AuthenticateAPIKey(mongikClient *models.Mongik, key string) (*model.APIKey, *string) {
+	keyId, secret, parseErr := parseAPIKey(key)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	apiKey, err := getAPIKey(mongikClient, keyId)
+	if err != nil {
+		return nil, &constants.ERROR_INVALID_API_KEY
+	}
+	if checkErr := checkAPIKey(apiKey, secret); checkErr != nil {
+		return nil, checkErr
+	}
+
+	apiKey.ResolveRoleSet()
+
+	// Last used is only tracked to the minute so that busy keys do not write on every request
+	if apiKey.LastUsedAt == nil || time.Since(apiKey.LastUsedAt.Time()) > constants.API_KEY_LAST_USED_INTERVAL {
+		go touchAPIKey(mongikClient, apiKey.Id)
+	}
+
+	return apiKey, nil
+}
+
+func touchAPIKey(mongikClient *models.Mongik, keyId primitive.ObjectID) {
+	apiKeyCollection := mongikClient.MongoClient.Database(constants.DB).Collection(constants.COLLECTION_API_KEY)
+	if _, err := apiKeyCollection.UpdateOne(context.Background(), bson.M{"_id": keyId}, bson.M{
+		"$set": bson.M{"lastUsedAt": primitive.NewDateTimeFromTime(time.Now())},
+	}); err != nil {
+		fmt.Println("Error updating last used of API key", keyId.Hex(), err)
+	}
+}
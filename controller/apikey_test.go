@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseAPIKey(t *testing.T) {
+	keyId := primitive.NewObjectID()
+	key, keyHash, err := newAPIKeySecret(keyId)
+	if err != nil {
+		t.Fatalf("newAPIKeySecret() error = %s", *err)
+	}
+	if !strings.HasPrefix(key, constants.API_KEY_PREFIX+"_"+keyId.Hex()+"_") {
+		t.Fatalf("newAPIKeySecret() = %s, want %s_%s_<secret>", key, constants.API_KEY_PREFIX, keyId.Hex())
+	}
+
+	parsedId, secret, err := parseAPIKey(key)
+	if err != nil {
+		t.Fatalf("parseAPIKey() error = %s", *err)
+	}
+	if parsedId != keyId || hashAPIKeySecret(secret) != keyHash {
+		t.Errorf("parseAPIKey() = %s, want the key id %s and the secret that was hashed", parsedId.Hex(), keyId.Hex())
+	}
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"empty", ""},
+		{"another prefix", "key_" + keyId.Hex() + "_secret"},
+		{"no secret", constants.API_KEY_PREFIX + "_" + keyId.Hex()},
+		{"empty secret", constants.API_KEY_PREFIX + "_" + keyId.Hex() + "_"},
+		{"invalid key id", constants.API_KEY_PREFIX + "_nope_secret"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, _, err := parseAPIKey(test.key); err == nil || *err != constants.ERROR_INVALID_API_KEY {
+				t.Errorf("parseAPIKey(%q) error = %v, want %s", test.key, err, constants.ERROR_INVALID_API_KEY)
+			}
+		})
+	}
+}
+
+func TestCheckAPIKey(t *testing.T) {
+	past := primitive.NewDateTimeFromTime(time.Now().Add(-time.Minute))
+	future := primitive.NewDateTimeFromTime(time.Now().Add(time.Hour))
+	keyHash := hashAPIKeySecret("secret")
+
+	tests := []struct {
+		name   string
+		apiKey model.APIKey
+		secret string
+		err    string
+	}{
+		{"valid", model.APIKey{KeyHash: keyHash}, "secret", ""},
+		{"not expired yet", model.APIKey{KeyHash: keyHash, ExpiresAt: &future}, "secret", ""},
+		{"wrong secret", model.APIKey{KeyHash: keyHash}, "guess", constants.ERROR_INVALID_API_KEY},
+		{"revoked", model.APIKey{KeyHash: keyHash, Revoked: true}, "secret", constants.ERROR_API_KEY_REVOKED},
+		{"expired", model.APIKey{KeyHash: keyHash, ExpiresAt: &past}, "secret", constants.ERROR_API_KEY_EXPIRED},
+		{"wrong secret of a revoked key", model.APIKey{KeyHash: keyHash, Revoked: true}, "guess", constants.ERROR_INVALID_API_KEY},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkAPIKey(&test.apiKey, test.secret)
+			if test.err == "" && err != nil {
+				t.Errorf("checkAPIKey() error = %s, want none", *err)
+			}
+			if test.err != "" && (err == nil || *err != test.err) {
+				t.Errorf("checkAPIKey() error = %v, want %s", err, test.err)
+			}
+		})
+	}
+}
+
+func TestCreateAPIKeyRefusedRoles(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []string
+		err   string
+	}{
+		{"unknown role", []string{"SOMETHING_ELSE"}, constants.ERROR_UNKNOWN_ROLE},
+		{"sensitive role", []string{constants.ROLE_ADMIN}, constants.ERROR_API_KEY_SENSITIVE_ROLE},
+		{"sensitive role among others", []string{constants.ROLE_GROUP_READ, constants.ROLE_ADMIN}, constants.ERROR_API_KEY_SENSITIVE_ROLE},
+	}
+
+	// Refused before anything is stored, so no database is needed
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, _, err := CreateAPIKey(nil, "key", primitive.NewObjectID(), test.roles, nil); err == nil || *err != test.err {
+				t.Errorf("CreateAPIKey() error = %v, want %s", err, test.err)
+			}
+		})
+	}
+}
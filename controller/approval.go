@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
+	db "github.com/FrosTiK-SD/mongik/db"
+	models "github.com/FrosTiK-SD/mongik/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var sensitiveRoles []string
+var loadSensitiveRolesOnce sync.Once
+
+// Roles listed in SENSITIVE_ROLES, only ADMIN when unset
+func GetSensitiveRoles() []string {
+	loadSensitiveRolesOnce.Do(func() {
+		sensitiveRoles = splitList(os.Getenv(constants.SENSITIVE_ROLES))
+		if len(sensitiveRoles) == 0 {
+			sensitiveRoles = []string{constants.ROLE_ADMIN}
+		}
+	})
+	return sensitiveRoles
+}
+
+// Whether the roles, once expanded through the role graph, carry a sensitive role
+func IsSensitive(roles []string) bool {
+	roleSet := util.NewRoleSetFromRoles(roles)
+	for _, role := range GetSensitiveRoles() {
+		if roleSet.Has(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether any of the groups carries a sensitive role
+func HasPrivilegedGroup(mongikClient *models.Mongik, groupIds []primitive.ObjectID, noCache bool) (bool, error) {
+	groups, err := GetGroupScopes(mongikClient, groupIds, noCache)
+	if err != nil {
+		return false, err
+	}
+	for _, group := range groups {
+		if IsSensitive(group.Roles) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func CreatePendingChange(mongikClient *models.Mongik, pendingChange *model.PendingChange) *string {
+	now := time.Now()
+	pendingChange.Id = primitive.NewObjectID()
+	pendingChange.Status = model.PENDING_CHANGE_PENDING
+	pendingChange.ExpiresAt = primitive.NewDateTimeFromTime(now.Add(constants.PENDING_CHANGE_TTL))
+	pendingChange.CreatedAt = primitive.NewDateTimeFromTime(now)
+
+	if _, err := db.InsertOne(mongikClient, constants.DB, constants.COLLECTION_PENDING_CHANGE, pendingChange); err != nil {
+		return &constants.ERROR_STORING_PENDING_CHANGE
+	}
+	return nil
+}
+
+// Marks changes nobody acted on in time as expired and lists the ones still waiting
+func GetPendingChanges(mongikClient *models.Mongik, noCache bool) (*[]model.PendingChange, error) {
+	if _, err := db.UpdateMany[model.PendingChange](mongikClient, constants.DB, constants.COLLECTION_PENDING_CHANGE, bson.M{
+		"status":    model.PENDING_CHANGE_PENDING,
+		"expiresAt": bson.M{"$lte": primitive.NewDateTimeFromTime(time.Now())},
+	}, bson.M{
+		"$set": bson.M{"status": model.PENDING_CHANGE_EXPIRED},
+	}); err != nil {
+		return nil, err
+	}
+
+	pendingChanges, err := db.Aggregate[model.PendingChange](mongikClient, constants.DB, constants.COLLECTION_PENDING_CHANGE, []bson.M{
+		{"$match": bson.M{"status": model.PENDING_CHANGE_PENDING}},
+		{"$sort": bson.M{"createdAt": -1}},
+	}, noCache)
+	return &pendingChanges, err
+}
+
+// The status a review moves the change to, changes past their expiry can only expire
+func reviewStatus(pendingChange *model.PendingChange, reviewer primitive.ObjectID, approve bool, now time.Time) (model.PendingChangeStatus, *string) {
+	if pendingChange.RequestedBy == reviewer {
+		return "", &constants.ERROR_SELF_APPROVAL
+	}
+	if pendingChange.ExpiresAt.Time().Before(now) {
+		return model.PENDING_CHANGE_EXPIRED, nil
+	}
+	if approve {
+		return model.PENDING_CHANGE_APPROVED, nil
+	}
+	return model.PENDING_CHANGE_REJECTED, nil
+}
+
+// Approves or rejects a pending change, approved changes are applied right away. The requester cannot review their own change
+func ReviewPendingChange(mongikClient *models.Mongik, changeId primitive.ObjectID, reviewer primitive.ObjectID, approve bool) (*model.PendingChange, *string) {
+	var pendingChange model.PendingChange
+	pendingChangeCollection := mongikClient.MongoClient.Database(constants.DB).Collection(constants.COLLECTION_PENDING_CHANGE)
+	if err := pendingChangeCollection.FindOne(context.Background(), bson.M{
+		"_id":    changeId,
+		"status": model.PENDING_CHANGE_PENDING,
+	}).Decode(&pendingChange); err != nil {
+		return nil, &constants.ERROR_PENDING_CHANGE_NOT_FOUND
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	status, reviewErr := reviewStatus(&pendingChange, reviewer, approve, now.Time())
+	if reviewErr != nil {
+		return nil, reviewErr
+	}
+
+	// Only the first review of a change wins, so it is never applied twice
+	updateResult, err := db.UpdateMany[model.PendingChange](mongikClient, constants.DB, constants.COLLECTION_PENDING_CHANGE, bson.M{
+		"_id":    changeId,
+		"status": model.PENDING_CHANGE_PENDING,
+	}, bson.M{
+		"$set": bson.M{
+			"status":     status,
+			"reviewedBy": reviewer,
+			"reviewedAt": now,
+		},
+	})
+	if err != nil {
+		return nil, &constants.ERROR_STORING_PENDING_CHANGE
+	}
+	if updateResult.ModifiedCount == 0 {
+		return nil, &constants.ERROR_PENDING_CHANGE_NOT_FOUND
+	}
+	if status == model.PENDING_CHANGE_EXPIRED {
+		return nil, &constants.ERROR_PENDING_CHANGE_EXPIRED
+	}
+
+	pendingChange.Status = status
+	pendingChange.ReviewedBy = &reviewer
+	pendingChange.ReviewedAt = &now
+	if !approve {
+		return &pendingChange, nil
+	}
+
+	if errs := applyPendingChange(mongikClient, &pendingChange); len(errs) != 0 {
+		return &pendingChange, &constants.ERROR_STORING_PENDING_CHANGE
+	}
+	return &pendingChange, nil
+}
+
+func applyPendingChange(mongikClient *models.Mongik, pendingChange *model.PendingChange) []error {
+	switch pendingChange.Kind {
+	case model.PENDING_CHANGE_GROUP_ASSIGN:
+		_, _, errs := BatchAssignGroup(mongikClient, []interfaces.BatchAssignGroupRequest{{
+			Action:     constants.ACTION_PUSH,
+			Groups:     pendingChange.Groups,
+			Students:   pendingChange.Students,
+			ValidFrom:  pendingChange.ValidFrom,
+			ValidUntil: pendingChange.ValidUntil,
+		}})
+		return errs
+	case model.PENDING_CHANGE_GROUP_EDIT:
+		_, _, errs := BatchEditGroup(mongikClient, []interfaces.AssignRequest{{
+			Action: constants.ACTION_PUSH,
+			Groups: pendingChange.Groups,
+			Roles:  pendingChange.Roles,
+		}}, true)
+		return *errs
+	case model.PENDING_CHANGE_GROUP_SCOPE:
+		if _, err := SetGroupScope(mongikClient, pendingChange.Groups, pendingChange.Scope); err != nil {
+			return []error{err}
+		}
+		return nil
+	case model.PENDING_CHANGE_GROUP_CREATE:
+		if _, err := BatchCreateGroup(mongikClient, pendingChange.NewGroups); err != nil {
+			return []error{err}
+		}
+		return nil
+	}
+	return []error{errors.New("unknown pending change kind " + string(pendingChange.Kind))}
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReviewStatus(t *testing.T) {
+	requester := primitive.NewObjectID()
+	reviewer := primitive.NewObjectID()
+	now := time.Now()
+	pending := model.PendingChange{RequestedBy: requester, ExpiresAt: primitive.NewDateTimeFromTime(now.Add(time.Hour))}
+	expired := model.PendingChange{RequestedBy: requester, ExpiresAt: primitive.NewDateTimeFromTime(now.Add(-time.Minute))}
+
+	tests := []struct {
+		name          string
+		pendingChange model.PendingChange
+		reviewer      primitive.ObjectID
+		approve       bool
+		status        model.PendingChangeStatus
+		err           string
+	}{
+		{"approve", pending, reviewer, true, model.PENDING_CHANGE_APPROVED, ""},
+		{"reject", pending, reviewer, false, model.PENDING_CHANGE_REJECTED, ""},
+		{"expired", expired, reviewer, true, model.PENDING_CHANGE_EXPIRED, ""},
+		{"self approval", pending, requester, true, "", constants.ERROR_SELF_APPROVAL},
+		{"self rejection", pending, requester, false, "", constants.ERROR_SELF_APPROVAL},
+		{"self approval of an expired change", expired, requester, true, "", constants.ERROR_SELF_APPROVAL},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, err := reviewStatus(&test.pendingChange, test.reviewer, test.approve, now)
+			if test.err != "" {
+				if err == nil || *err != test.err {
+					t.Errorf("reviewStatus() error = %v, want %s", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reviewStatus() error = %s", *err)
+			}
+			if status != test.status {
+				t.Errorf("reviewStatus() = %s, want %s", status, test.status)
+			}
+		})
+	}
+}
+
+func TestIsSensitive(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []string
+		want  bool
+	}{
+		{"no roles", nil, false},
+		{"plain roles", []string{constants.ROLE_STUDENT, constants.ROLE_GROUP_EDIT}, false},
+		{"sensitive role", []string{constants.ROLE_STUDENT, constants.ROLE_ADMIN}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsSensitive(test.roles); got != test.want {
+				t.Errorf("IsSensitive(%v) = %v, want %v", test.roles, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/util"
+)
+
+type claimPolicyDefaults struct {
+	requireEmailVerified     bool
+	allowedSignInProviders   []string
+	instituteSignInProviders []string
+	sensitiveMaxAuthAge      time.Duration
+}
+
+var defaultClaimPolicy claimPolicyDefaults
+var loadClaimPolicyOnce sync.Once
+
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Verified emails are required unless REQUIRE_EMAIL_VERIFIED=false, providers are unrestricted unless listed
+func getClaimPolicyDefaults() *claimPolicyDefaults {
+	loadClaimPolicyOnce.Do(func() {
+		defaultClaimPolicy = claimPolicyDefaults{
+			requireEmailVerified:     true,
+			allowedSignInProviders:   splitList(os.Getenv(constants.ALLOWED_SIGN_IN_PROVIDERS)),
+			instituteSignInProviders: splitList(os.Getenv(constants.INSTITUTE_SIGN_IN_PROVIDERS)),
+			sensitiveMaxAuthAge:      constants.DEFAULT_SENSITIVE_MAX_AUTH_AGE,
+		}
+
+		if requireEmailVerified, err := strconv.ParseBool(os.Getenv(constants.REQUIRE_EMAIL_VERIFIED)); err == nil {
+			defaultClaimPolicy.requireEmailVerified = requireEmailVerified
+		}
+		if maxAuthAge := os.Getenv(constants.SENSITIVE_MAX_AUTH_AGE); maxAuthAge != "" {
+			if duration, err := time.ParseDuration(maxAuthAge); err == nil && duration > 0 {
+				defaultClaimPolicy.sensitiveMaxAuthAge = duration
+			} else {
+				fmt.Println("Invalid SENSITIVE_MAX_AUTH_AGE, using the default:", maxAuthAge)
+			}
+		}
+	})
+
+	return &defaultClaimPolicy
+}
+
+// How recently a principal must have signed in to use sensitive routes
+func GetSensitiveMaxAuthAge() time.Duration {
+	return getClaimPolicyDefaults().sensitiveMaxAuthAge
+}
+
+// Checks email_verified and firebase.sign_in_provider against the policy of the issuer
+func CheckClaimPolicy(issuer *interfaces.TrustedIssuer, token *interfaces.Token) *string {
+	if issuer.FirstParty {
+		return nil
+	}
+	defaults := getClaimPolicyDefaults()
+
+	requireEmailVerified := defaults.requireEmailVerified
+	if issuer.RequireEmailVerified != nil {
+		requireEmailVerified = *issuer.RequireEmailVerified
+	}
+	if requireEmailVerified && !token.EmailVerified {
+		return &constants.ERROR_EMAIL_NOT_VERIFIED
+	}
+
+	allowedSignInProviders := defaults.allowedSignInProviders
+	if issuer.AllowedSignInProviders != nil {
+		allowedSignInProviders = issuer.AllowedSignInProviders
+	}
+	if len(allowedSignInProviders) != 0 && !util.ArrayContains(allowedSignInProviders, token.Firebase.SignInProvider) {
+		return &constants.ERROR_SIGN_IN_PROVIDER_NOT_ALLOWED
+	}
+
+	// Institute accounts can be held to a stricter list, such as only google.com
+	instituteSignInProviders := defaults.instituteSignInProviders
+	if issuer.InstituteSignInProviders != nil {
+		instituteSignInProviders = issuer.InstituteSignInProviders
+	}
+	if len(instituteSignInProviders) != 0 && util.CheckValidInstituteEmail(token.Email) && !util.ArrayContains(instituteSignInProviders, token.Firebase.SignInProvider) {
+		return &constants.ERROR_SIGN_IN_PROVIDER_NOT_ALLOWED
+	}
+
+	return nil
+}
+
+// Rejects tokens whose sign-in is older than maxAge
+func CheckAuthAge(token *interfaces.Token, maxAge time.Duration) *string {
+	if token == nil || token.AuthTime == 0 {
+		return &constants.ERROR_AUTH_TIME_MISSING
+	}
+	if time.Since(time.Unix(int64(token.AuthTime), 0)) > maxAge {
+		return &constants.ERROR_REAUTHENTICATION_REQUIRED
+	}
+	return nil
+}
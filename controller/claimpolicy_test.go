@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+)
+
+func testPolicyToken(email string, emailVerified bool, signInProvider string) *interfaces.Token {
+	token := &interfaces.Token{Email: email, EmailVerified: emailVerified}
+	token.Firebase.SignInProvider = signInProvider
+	return token
+}
+
+func TestCheckClaimPolicy(t *testing.T) {
+	required, notRequired := true, false
+
+	// Every case sets its own policy so that the defaults from the environment do not matter
+	strict := interfaces.TrustedIssuer{
+		RequireEmailVerified:     &required,
+		AllowedSignInProviders:   []string{"google.com", "password"},
+		InstituteSignInProviders: []string{"google.com"},
+	}
+	lenient := interfaces.TrustedIssuer{
+		RequireEmailVerified:     &notRequired,
+		AllowedSignInProviders:   []string{},
+		InstituteSignInProviders: []string{},
+	}
+
+	tests := []struct {
+		name   string
+		issuer interfaces.TrustedIssuer
+		token  *interfaces.Token
+		err    string
+	}{
+		{"allowed", strict, testPolicyToken("someone@gmail.com", true, "password"), ""},
+		{"email not verified", strict, testPolicyToken("someone@gmail.com", false, "password"), constants.ERROR_EMAIL_NOT_VERIFIED},
+		{"email verification not required", lenient, testPolicyToken("someone@gmail.com", false, "password"), ""},
+		{"provider not allowed", strict, testPolicyToken("someone@gmail.com", true, "phone"), constants.ERROR_SIGN_IN_PROVIDER_NOT_ALLOWED},
+		{"institute account with the institute provider", strict, testPolicyToken("someone.cse20@iitbhu.ac.in", true, "google.com"), ""},
+		{"institute account with a password", strict, testPolicyToken("someone.cse20@iitbhu.ac.in", true, "password"), constants.ERROR_SIGN_IN_PROVIDER_NOT_ALLOWED},
+		{"institute alias with a password", strict, testPolicyToken("someone.cse20@itbhu.ac.in", true, "password"), constants.ERROR_SIGN_IN_PROVIDER_NOT_ALLOWED},
+		{"any provider when none are listed", lenient, testPolicyToken("someone.cse20@iitbhu.ac.in", true, "phone"), ""},
+		{"first party tokens were checked already", interfaces.TrustedIssuer{RequireEmailVerified: &required, FirstParty: true}, testPolicyToken("someone@gmail.com", false, ""), ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckClaimPolicy(&test.issuer, test.token)
+			if test.err == "" && err != nil {
+				t.Errorf("CheckClaimPolicy() error = %s, want none", *err)
+			}
+			if test.err != "" && (err == nil || *err != test.err) {
+				t.Errorf("CheckClaimPolicy() error = %v, want %s", err, test.err)
+			}
+		})
+	}
+}
+
+func TestCheckAuthAge(t *testing.T) {
+	tests := []struct {
+		name  string
+		token *interfaces.Token
+		err   string
+	}{
+		{"recent", &interfaces.Token{AuthTime: int(time.Now().Add(-time.Minute).Unix())}, ""},
+		{"stale", &interfaces.Token{AuthTime: int(time.Now().Add(-time.Hour).Unix())}, constants.ERROR_REAUTHENTICATION_REQUIRED},
+		{"no auth_time", &interfaces.Token{}, constants.ERROR_AUTH_TIME_MISSING},
+		{"no token", nil, constants.ERROR_AUTH_TIME_MISSING},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckAuthAge(test.token, 10*time.Minute)
+			if test.err == "" && err != nil {
+				t.Errorf("CheckAuthAge() error = %s, want none", *err)
+			}
+			if test.err != "" && (err == nil || *err != test.err) {
+				t.Errorf("CheckAuthAge() error = %v, want %s", err, test.err)
+			}
+		})
+	}
+}
+
+func TestVerifyTokenClaimPolicy(t *testing.T) {
+	signKey, publicSet := newTestSigningKey(t, "key")
+	required := true
+	keyManager := newTestKeyManager(t, IssuerSource{
+		Issuer: interfaces.TrustedIssuer{
+			Issuer:                 testIssuer,
+			Audience:               testAudience,
+			EmailClaim:             constants.DEFAULT_EMAIL_CLAIM,
+			RequireEmailVerified:   &required,
+			AllowedSignInProviders: []string{"google.com"},
+		},
+		Source: NewStaticJWKSource(publicSet),
+	})
+
+	claims := testClaims()
+	claims["email_verified"] = false
+	if _, _, err := VerifyToken(keyManager, nil, signTestToken(t, signKey, claims)); err == nil || *err != constants.ERROR_EMAIL_NOT_VERIFIED {
+		t.Errorf("VerifyToken() error = %v, want %s", err, constants.ERROR_EMAIL_NOT_VERIFIED)
+	}
+
+	claims = testClaims()
+	claims["firebase"] = map[string]interface{}{"sign_in_provider": "google.com"}
+	if _, _, err := VerifyToken(keyManager, nil, signTestToken(t, signKey, claims)); err != nil {
+		t.Errorf("VerifyToken() error = %s, want none", *err)
+	}
+}
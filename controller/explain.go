@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
+	mongikModels "github.com/FrosTiK-SD/mongik/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Replays the route policy and scope checks for the student without making the request
+func ExplainAccess(mongikClient *mongikModels.Mongik, student *model.StudentPopulated, target *model.StudentPopulated, method string, route string, noCache bool) (*interfaces.ExplainResponse, error) {
+	permissions, err := GetPermissions(mongikClient, student, noCache)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation := interfaces.ExplainResponse{
+		Method: strings.ToUpper(method),
+		Path:   route,
+		Roles:  permissions.Roles,
+		Groups: permissions.Groups,
+	}
+
+	rule, expression := GetRoutePolicy().Match(explanation.Method, route)
+	if rule == nil {
+		explanation.Reason = "No policy matches the route, so it is denied"
+		return &explanation, nil
+	}
+	explanation.Rule = rule
+	explanation.RecentAuthRequired = rule.RecentAuth
+
+	switch rule.Principal {
+	case interfaces.POLICY_PUBLIC:
+		explanation.Allowed = true
+		explanation.Reason = "The route is public"
+		return &explanation, nil
+	case interfaces.POLICY_RECRUITER:
+		explanation.Reason = "The route is only for recruiters"
+		return &explanation, nil
+	}
+
+	if expression == nil {
+		explanation.Allowed = true
+		explanation.Reason = "Any signed in student may call the route"
+		return &explanation, nil
+	}
+
+	if met, unmet := expression.Evaluate(student.RoleSet()); !met {
+		explanation.Reason = "The role requirement is not met"
+		explanation.Unmet = unmet
+
+		groups, err := GetAllGroups(mongikClient, noCache)
+		if err != nil {
+			return nil, err
+		}
+		studentGroups := map[primitive.ObjectID]struct{}{}
+		for _, group := range student.GroupDetails {
+			studentGroups[group.ID] = struct{}{}
+		}
+		for _, group := range *groups {
+			if _, found := studentGroups[group.ID]; found {
+				continue
+			}
+			roles := append(student.RoleSet().List(), group.Roles...)
+			if met, _ := expression.Evaluate(util.NewRoleSetFromRoles(roles)); met {
+				explanation.GrantingGroups = append(explanation.GrantingGroups, interfaces.PermissionsGroup{
+					Id:    group.ID,
+					Name:  group.Name,
+					Roles: group.Roles,
+				})
+			}
+		}
+		return &explanation, nil
+	}
+
+	if target != nil {
+		scopedRoleSet, err := GetScopedRoleSet(mongikClient, student, target, noCache)
+		if err != nil {
+			return nil, err
+		}
+		if met, unmet := expression.Evaluate(scopedRoleSet); !met {
+			explanation.Reason = "The target student is outside the scope of the groups granting the roles"
+			explanation.Unmet = unmet
+			return &explanation, nil
+		}
+	}
+
+	explanation.Allowed = true
+	explanation.Reason = "The role requirement is met"
+	return &explanation, nil
+}
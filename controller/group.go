@@ -115,6 +115,9 @@ func BatchAssignGroup(mongikClient *mongikModels.Mongik, assignRequests []interf
 			if err != nil {
 				errors = append(errors, err)
 			}
+			if err := SetMemberships(mongikClient, assignRequests[idx].Students, assignRequests[idx].Groups, assignRequests[idx].ValidFrom, assignRequests[idx].ValidUntil); err != nil {
+				errors = append(errors, err)
+			}
 		case constants.ACTION_PULL:
 			removeResult, err := db.UpdateMany[model.StudentPopulated](mongikClient, constants.DB, constants.COLLECTION_STUDENT, bson.M{
 				"_id": bson.M{
@@ -131,6 +134,9 @@ func BatchAssignGroup(mongikClient *mongikModels.Mongik, assignRequests []interf
 			if err != nil {
 				errors = append(errors, err)
 			}
+			if err := DeleteMemberships(mongikClient, assignRequests[idx].Students, assignRequests[idx].Groups); err != nil {
+				errors = append(errors, err)
+			}
 		}
 
 	}
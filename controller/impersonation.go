@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/model"
+	db "github.com/FrosTiK-SD/mongik/db"
+	models "github.com/FrosTiK-SD/mongik/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func activeImpersonationFilter(actor primitive.ObjectID) bson.M {
+	return bson.M{
+		"actor":     actor,
+		"endedAt":   nil,
+		"expiresAt": bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())},
+	}
+}
+
+// Impersonating must never grant a role the actor does not hold, so the roles of the target have to be
+// a subset of the roles of the actor. Targets holding a sensitive role are refused outright
+func CheckImpersonationTarget(actor *model.StudentPopulated, target *model.StudentPopulated) *string {
+	targetRoles := target.RoleSet()
+	if IsSensitive(targetRoles.List()) {
+		return &constants.ERROR_IMPERSONATION_PRIVILEGED_TARGET
+	}
+
+	actorRoles := actor.RoleSet()
+	for role := range targetRoles {
+		if !actorRoles.Has(role) {
+			return &constants.ERROR_IMPERSONATION_PRIVILEGED_TARGET
+		}
+	}
+	return nil
+}
+
+// Read-only impersonations may only make requests that change nothing
+func CheckImpersonationMethod(impersonation *model.Impersonation, method string) *string {
+	if impersonation.ReadOnly && method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions {
+		return &constants.ERROR_IMPERSONATION_READ_ONLY
+	}
+	return nil
+}
+
+// An actor impersonates one student at a time, starting a new session ends the previous one
+func StartImpersonation(mongikClient *models.Mongik, actor primitive.ObjectID, target primitive.ObjectID, reason string, readOnly bool, duration time.Duration) (*model.Impersonation, *string) {
+	if actor == target || reason == "" {
+		return nil, &constants.ERROR_INVALID_IMPERSONATION
+	}
+	if duration <= 0 {
+		duration = constants.DEFAULT_IMPERSONATION_DURATION
+	}
+	if duration > constants.MAX_IMPERSONATION_DURATION {
+		duration = constants.MAX_IMPERSONATION_DURATION
+	}
+
+	if _, err := StopImpersonation(mongikClient, actor); err != nil {
+		return nil, &constants.ERROR_STORING_IMPERSONATION
+	}
+
+	now := time.Now()
+	impersonation := model.Impersonation{
+		Id:        primitive.NewObjectID(),
+		Actor:     actor,
+		Target:    target,
+		Reason:    reason,
+		ReadOnly:  readOnly,
+		ExpiresAt: primitive.NewDateTimeFromTime(now.Add(duration)),
+		CreatedAt: primitive.NewDateTimeFromTime(now),
+	}
+
+	if _, err := db.InsertOne(mongikClient, constants.DB, constants.COLLECTION_IMPERSONATION, impersonation); err != nil {
+		return nil, &constants.ERROR_STORING_IMPERSONATION
+	}
+
+	return &impersonation, nil
+}
+
+func StopImpersonation(mongikClient *models.Mongik, actor primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return db.UpdateMany[model.Impersonation](mongikClient, constants.DB, constants.COLLECTION_IMPERSONATION, activeImpersonationFilter(actor), bson.M{
+		"$set": bson.M{"endedAt": primitive.NewDateTimeFromTime(time.Now())},
+	})
+}
+
+// Read straight from Mongo so that stopping a session takes effect on the next request
+func GetActiveImpersonation(mongikClient *models.Mongik, actor primitive.ObjectID) (*model.Impersonation, error) {
+	var impersonation model.Impersonation
+	impersonationCollection := mongikClient.MongoClient.Database(constants.DB).Collection(constants.COLLECTION_IMPERSONATION)
+	if err := impersonationCollection.FindOne(context.Background(), activeImpersonationFilter(actor)).Decode(&impersonation); err != nil {
+		return nil, err
+	}
+	return &impersonation, nil
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/model"
+)
+
+func testStudent(roles ...string) *model.StudentPopulated {
+	student := &model.StudentPopulated{}
+	student.SetResolvedRoles(roles)
+	return student
+}
+
+func TestCheckImpersonationTarget(t *testing.T) {
+	actor := testStudent(constants.ROLE_STUDENT, constants.ROLE_OPPORTUNITIES_WRITE, constants.ROLE_TPR)
+
+	tests := []struct {
+		name   string
+		actor  *model.StudentPopulated
+		target *model.StudentPopulated
+		err    string
+	}{
+		{"student", actor, testStudent(constants.ROLE_STUDENT), ""},
+		{"subset of the roles of the actor", actor, testStudent(constants.ROLE_STUDENT, constants.ROLE_TPR), ""},
+		{"role the actor does not hold", actor, testStudent(constants.ROLE_STUDENT, constants.ROLE_GROUP_EDIT), constants.ERROR_IMPERSONATION_PRIVILEGED_TARGET},
+		{"sensitive target", testStudent(constants.ROLE_STUDENT, constants.ROLE_OPPORTUNITIES_WRITE, constants.ROLE_ADMIN), testStudent(constants.ROLE_STUDENT, constants.ROLE_ADMIN), constants.ERROR_IMPERSONATION_PRIVILEGED_TARGET},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckImpersonationTarget(test.actor, test.target)
+			if test.err == "" && err != nil {
+				t.Errorf("CheckImpersonationTarget() error = %s, want none", *err)
+			}
+			if test.err != "" && (err == nil || *err != test.err) {
+				t.Errorf("CheckImpersonationTarget() error = %v, want %s", err, test.err)
+			}
+		})
+	}
+}
+
+func TestCheckImpersonationMethod(t *testing.T) {
+	tests := []struct {
+		name     string
+		readOnly bool
+		method   string
+		allowed  bool
+	}{
+		{"read-only GET", true, http.MethodGet, true},
+		{"read-only HEAD", true, http.MethodHead, true},
+		{"read-only OPTIONS", true, http.MethodOptions, true},
+		{"read-only POST", true, http.MethodPost, false},
+		{"read-only DELETE", true, http.MethodDelete, false},
+		{"read-write POST", false, http.MethodPost, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckImpersonationMethod(&model.Impersonation{ReadOnly: test.readOnly}, test.method)
+			if test.allowed && err != nil {
+				t.Errorf("CheckImpersonationMethod() error = %s, want none", *err)
+			}
+			if !test.allowed && (err == nil || *err != constants.ERROR_IMPERSONATION_READ_ONLY) {
+				t.Errorf("CheckImpersonationMethod() error = %v, want %s", err, constants.ERROR_IMPERSONATION_READ_ONLY)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/FrosTiK-SD/auth/constants"
+)
+
+var introspectionClients map[string]string
+var loadIntrospectionClientsOnce sync.Once
+
+// Clients come from INTROSPECTION_CLIENTS as comma separated clientId:clientSecret pairs
+func getIntrospectionClients() map[string]string {
+	loadIntrospectionClientsOnce.Do(func() {
+		introspectionClients = map[string]string{}
+		for _, client := range strings.Split(os.Getenv(constants.INTROSPECTION_CLIENTS), ",") {
+			clientId, clientSecret, found := strings.Cut(strings.TrimSpace(client), ":")
+			if !found || clientId == "" || clientSecret == "" {
+				continue
+			}
+			introspectionClients[clientId] = clientSecret
+		}
+	})
+
+	return introspectionClients
+}
+
+func AuthenticateIntrospectionClient(clientId string, clientSecret string) bool {
+	expectedSecret, found := getIntrospectionClients()[clientId]
+	if !found {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expectedSecret), []byte(clientSecret)) == 1
+}
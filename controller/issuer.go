@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	jsoniter "github.com/json-iterator/go"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+var trustedIssuers []interfaces.TrustedIssuer
+var loadTrustedIssuersOnce sync.Once
+
+func GetDefaultFirebaseIssuer() interfaces.TrustedIssuer {
+	projectId := os.Getenv(constants.FIREBASE_PROJECT_ID)
+	return interfaces.TrustedIssuer{
+		Issuer:     constants.FIREBASE_ISSUER_PREFIX + projectId,
+		JWKSURL:    constants.FIREBASE_JWKS_URL,
+		Audience:   projectId,
+		EmailClaim: constants.DEFAULT_EMAIL_CLAIM,
+	}
+}
+
+// Reads a JSON array of issuers. Every issuer needs an audience and a place to get its keys from
+func LoadTrustedIssuers(configPath string) ([]interfaces.TrustedIssuer, error) {
+	configBytes, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var issuers []interfaces.TrustedIssuer
+	if err := json.Unmarshal(configBytes, &issuers); err != nil {
+		return nil, err
+	}
+	if len(issuers) == 0 {
+		return nil, errors.New("no trusted issuers configured")
+	}
+
+	for idx := range issuers {
+		if issuers[idx].Issuer == "" || issuers[idx].Audience == "" {
+			return nil, fmt.Errorf("issuer %d is missing the issuer or audience", idx)
+		}
+		if issuers[idx].DiscoveryURL == "" && issuers[idx].JWKSURL == "" && issuers[idx].JWKSFile == "" && len(issuers[idx].JWKS) == 0 {
+			return nil, fmt.Errorf("issuer %s needs a discoveryUrl, jwksUrl, jwksFile or jwks", issuers[idx].Issuer)
+		}
+		if issuers[idx].EmailClaim == "" {
+			issuers[idx].EmailClaim = constants.DEFAULT_EMAIL_CLAIM
+		}
+	}
+
+	return issuers, nil
+}
+
+// Issuers come from the file in TRUSTED_ISSUERS_CONFIG, otherwise only Firebase is trusted
+func GetTrustedIssuers() []interfaces.TrustedIssuer {
+	loadTrustedIssuersOnce.Do(func() {
+		trustedIssuers = []interfaces.TrustedIssuer{GetDefaultFirebaseIssuer()}
+
+		configPath := os.Getenv(constants.TRUSTED_ISSUERS_CONFIG)
+		if configPath == "" {
+			return
+		}
+
+		issuers, err := LoadTrustedIssuers(configPath)
+		if err != nil {
+			fmt.Println("Error loading trusted issuers, falling back to Firebase:", err)
+			return
+		}
+		trustedIssuers = issuers
+	})
+
+	return trustedIssuers
+}
@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// Where the keys of an issuer come from. Fetch returns the set and how long it may be used
+type JWKSource interface {
+	Fetch() (jwk.Set, time.Duration, *string)
+}
+
+// Keys served over HTTP, either directly or through an OpenID discovery document
+type RemoteJWKSource struct {
+	URL          string
+	DiscoveryURL string
+	Issuer       string
+	HTTPClient   *http.Client
+}
+
+// Keys read from a JWKS file on disk. The file is re-read periodically so keys can be rotated
+type FileJWKSource struct {
+	Path string
+}
+
+// Keys that never change, for tests and locally signed tokens
+type StaticJWKSource struct {
+	Set jwk.Set
+}
+
+func NewRemoteJWKSource(url string, httpClient *http.Client) *RemoteJWKSource {
+	return &RemoteJWKSource{
+		URL:        url,
+		HTTPClient: httpClient,
+	}
+}
+
+func NewDiscoveryJWKSource(discoveryURL string, issuer string, httpClient *http.Client) *RemoteJWKSource {
+	return &RemoteJWKSource{
+		DiscoveryURL: discoveryURL,
+		Issuer:       issuer,
+		HTTPClient:   httpClient,
+	}
+}
+
+func NewFileJWKSource(path string) *FileJWKSource {
+	return &FileJWKSource{
+		Path: path,
+	}
+}
+
+func NewStaticJWKSource(set jwk.Set) *StaticJWKSource {
+	return &StaticJWKSource{
+		Set: set,
+	}
+}
+
+// Picks the source an issuer is configured with
+func NewJWKSource(issuer *interfaces.TrustedIssuer, httpClient *http.Client) (JWKSource, *string) {
+	switch {
+	case issuer.DiscoveryURL != "":
+		return NewDiscoveryJWKSource(issuer.DiscoveryURL, issuer.Issuer, httpClient), nil
+	case issuer.JWKSURL != "":
+		return NewRemoteJWKSource(issuer.JWKSURL, httpClient), nil
+	case issuer.JWKSFile != "":
+		return NewFileJWKSource(issuer.JWKSFile), nil
+	case len(issuer.JWKS) != 0:
+		jwkSet, err := jwk.Parse(issuer.JWKS)
+		if err != nil {
+			return nil, &constants.ERROR_PARSING_JWK
+		}
+		return NewStaticJWKSource(jwkSet), nil
+	}
+
+	return nil, &constants.ERROR_FETCH_JWK
+}
+
+func (source *RemoteJWKSource) Fetch() (jwk.Set, time.Duration, *string) {
+	httpClient := source.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: constants.JWKS_FETCH_TIMEOUT}
+	}
+
+	jwksURL := source.URL
+	if jwksURL == "" {
+		discoveredURL, err := source.discoverJWKSURL(httpClient)
+		if err != nil {
+			return nil, 0, err
+		}
+		jwksURL = discoveredURL
+	}
+
+	jwks, err := httpClient.Get(jwksURL)
+	if err != nil {
+		return nil, 0, &constants.ERROR_FETCH_JWK
+	}
+	defer jwks.Body.Close()
+
+	if jwks.StatusCode != http.StatusOK {
+		return nil, 0, &constants.ERROR_FETCH_JWK
+	}
+
+	jwkBytes, err := io.ReadAll(jwks.Body)
+	if err != nil {
+		return nil, 0, &constants.ERROR_CONVERT_JWT_TO_BYTES
+	}
+
+	jwkSet, err := jwk.Parse(jwkBytes)
+	if err != nil {
+		return nil, 0, &constants.ERROR_PARSING_JWK
+	}
+
+	return jwkSet, getMaxAge(jwks.Header.Get(constants.CACHE_CONTROL_HEADER)), nil
+}
+
+func (source *RemoteJWKSource) discoverJWKSURL(httpClient *http.Client) (string, *string) {
+	discoveryResponse, err := httpClient.Get(source.DiscoveryURL)
+	if err != nil {
+		return "", &constants.ERROR_FETCH_DISCOVERY
+	}
+	defer discoveryResponse.Body.Close()
+
+	discoveryBytes, err := io.ReadAll(discoveryResponse.Body)
+	if err != nil {
+		return "", &constants.ERROR_FETCH_DISCOVERY
+	}
+
+	var discovery interfaces.OpenIDConfiguration
+	if err := json.Unmarshal(discoveryBytes, &discovery); err != nil || discovery.JWKSURI == "" {
+		return "", &constants.ERROR_FETCH_DISCOVERY
+	}
+
+	// The discovery document must describe the issuer we were configured with
+	if discovery.Issuer != source.Issuer {
+		return "", &constants.ERROR_UNTRUSTED_ISSUER
+	}
+
+	return discovery.JWKSURI, nil
+}
+
+func (source *FileJWKSource) Fetch() (jwk.Set, time.Duration, *string) {
+	jwkBytes, err := os.ReadFile(source.Path)
+	if err != nil {
+		return nil, 0, &constants.ERROR_FETCH_JWK
+	}
+
+	jwkSet, err := jwk.Parse(jwkBytes)
+	if err != nil {
+		return nil, 0, &constants.ERROR_PARSING_JWK
+	}
+
+	return jwkSet, constants.JWKS_MIN_REFRESH_INTERVAL, nil
+}
+
+func (source *StaticJWKSource) Fetch() (jwk.Set, time.Duration, *string) {
+	return source.Set, constants.JWKS_MAX_REFRESH_INTERVAL, nil
+}
+
+// Reads max-age out of a Cache-Control header, clamped to sane bounds
+func getMaxAge(cacheControl string) time.Duration {
+	maxAge := constants.JWKS_DEFAULT_MAX_AGE
+
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(directive), "=")
+		if !found || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		if seconds, err := strconv.Atoi(value); err == nil {
+			maxAge = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if maxAge < constants.JWKS_MIN_REFRESH_INTERVAL {
+		return constants.JWKS_MIN_REFRESH_INTERVAL
+	}
+	if maxAge > constants.JWKS_MAX_REFRESH_INTERVAL {
+		return constants.JWKS_MAX_REFRESH_INTERVAL
+	}
+	return maxAge
+}
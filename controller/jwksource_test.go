@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+)
+
+func TestGetMaxAge(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		want         time.Duration
+	}{
+		{"no header", "", constants.JWKS_DEFAULT_MAX_AGE},
+		{"max-age", "public, max-age=7200, must-revalidate", 2 * time.Hour},
+		{"case insensitive", "Max-Age=7200", 2 * time.Hour},
+		{"not a number", "max-age=soon", constants.JWKS_DEFAULT_MAX_AGE},
+		{"too short", "max-age=0", constants.JWKS_MIN_REFRESH_INTERVAL},
+		{"negative", "max-age=-60", constants.JWKS_MIN_REFRESH_INTERVAL},
+		{"too long", "max-age=31536000", constants.JWKS_MAX_REFRESH_INTERVAL},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := getMaxAge(test.cacheControl); got != test.want {
+				t.Errorf("getMaxAge(%q) = %s, want %s", test.cacheControl, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRemoteJWKSource(t *testing.T) {
+	_, publicSet := newTestSigningKey(t, "key")
+	jwksBytes, err := json.Marshal(publicSet)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(constants.CACHE_CONTROL_HEADER, "public, max-age=7200")
+		w.Write(jwksBytes)
+	})
+	mux.HandleFunc("/invalid", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not a key set"))
+	})
+	mux.HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"issuer": "` + server.URL + `", "jwks_uri": "` + server.URL + `/jwks"}`))
+	})
+
+	tests := []struct {
+		name   string
+		source *RemoteJWKSource
+		maxAge time.Duration
+		err    string
+	}{
+		{"jwks url", NewRemoteJWKSource(server.URL+"/jwks", server.Client()), 2 * time.Hour, ""},
+		{"discovery", NewDiscoveryJWKSource(server.URL+"/.well-known/openid-configuration", server.URL, server.Client()), 2 * time.Hour, ""},
+		{"discovery of another issuer", NewDiscoveryJWKSource(server.URL+"/.well-known/openid-configuration", "https://evil.example.com", server.Client()), 0, constants.ERROR_UNTRUSTED_ISSUER},
+		{"discovery unavailable", NewDiscoveryJWKSource(server.URL+"/down", server.URL, server.Client()), 0, constants.ERROR_FETCH_DISCOVERY},
+		{"unavailable", NewRemoteJWKSource(server.URL+"/down", server.Client()), 0, constants.ERROR_FETCH_JWK},
+		{"not a key set", NewRemoteJWKSource(server.URL+"/invalid", server.Client()), 0, constants.ERROR_PARSING_JWK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jwkSet, maxAge, err := test.source.Fetch()
+			if test.err != "" {
+				if err == nil || *err != test.err {
+					t.Errorf("Fetch() error = %v, want %s", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %s", *err)
+			}
+			if _, found := jwkSet.LookupKeyID("key"); !found {
+				t.Errorf("Fetch() is missing the key")
+			}
+			if maxAge != test.maxAge {
+				t.Errorf("Fetch() max age = %s, want %s", maxAge, test.maxAge)
+			}
+		})
+	}
+}
+
+func TestNewJWKSource(t *testing.T) {
+	_, publicSet := newTestSigningKey(t, "key")
+	jwksBytes, err := json.Marshal(publicSet)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	jwksFile := writeTestConfig(t, string(jwksBytes))
+
+	tests := []struct {
+		name   string
+		issuer interfaces.TrustedIssuer
+		maxAge time.Duration
+		err    string
+	}{
+		{"file", interfaces.TrustedIssuer{JWKSFile: jwksFile}, constants.JWKS_MIN_REFRESH_INTERVAL, ""},
+		{"static", interfaces.TrustedIssuer{JWKS: jwksBytes}, constants.JWKS_MAX_REFRESH_INTERVAL, ""},
+		{"missing file", interfaces.TrustedIssuer{JWKSFile: filepath.Join(t.TempDir(), "missing.json")}, 0, constants.ERROR_FETCH_JWK},
+		{"invalid static keys", interfaces.TrustedIssuer{JWKS: []byte(`{"keys": [{"kty": "nope"}]}`)}, 0, constants.ERROR_PARSING_JWK},
+		{"no keys", interfaces.TrustedIssuer{}, 0, constants.ERROR_FETCH_JWK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source, err := NewJWKSource(&test.issuer, nil)
+			if err == nil {
+				_, maxAge, fetchErr := source.Fetch()
+				if fetchErr == nil && maxAge != test.maxAge {
+					t.Errorf("Fetch() max age = %s, want %s", maxAge, test.maxAge)
+				}
+				err = fetchErr
+			}
+			if test.err == "" && err != nil {
+				t.Errorf("error = %s, want none", *err)
+			}
+			if test.err != "" && (err == nil || *err != test.err) {
+				t.Errorf("error = %v, want %s", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// An issuer along with where its keys come from
+type IssuerSource struct {
+	Issuer interfaces.TrustedIssuer
+	Source JWKSource
+}
+
+// Keeps the parsed keys of one issuer in memory
+type issuerKeys struct {
+	issuer      interfaces.TrustedIssuer
+	source      JWKSource
+	jwkSet      atomic.Pointer[jwk.Set]
+	lastFetch   atomic.Int64
+	refreshLock sync.Mutex
+}
+
+type KeyManager struct {
+	issuers  atomic.Pointer[map[string]*issuerKeys]
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewKeyManager(issuers []interfaces.TrustedIssuer) *KeyManager {
+	return NewKeyManagerWithClient(issuers, &http.Client{Timeout: constants.JWKS_FETCH_TIMEOUT})
+}
+
+// Remote issuers fetch their keys through the given client
+func NewKeyManagerWithClient(issuers []interfaces.TrustedIssuer, httpClient *http.Client) *KeyManager {
+	var issuerSources []IssuerSource
+	for idx := range issuers {
+		source, err := NewJWKSource(&issuers[idx], httpClient)
+		if err != nil {
+			fmt.Println("Error creating JWK source for", issuers[idx].Issuer, *err)
+			continue
+		}
+		issuerSources = append(issuerSources, IssuerSource{Issuer: issuers[idx], Source: source})
+	}
+
+	return NewKeyManagerFromSources(issuerSources)
+}
+
+// Fetches the keys of every issuer once and then keeps them fresh in the background
+func NewKeyManagerFromSources(issuerSources []IssuerSource) *KeyManager {
+	keyManager := &KeyManager{
+		stop: make(chan struct{}),
+	}
+	keyManager.issuers.Store(&map[string]*issuerKeys{})
+
+	for _, issuerSource := range issuerSources {
+		keyManager.AddIssuer(issuerSource)
+	}
+
+	return keyManager
+}
+
+// Starts trusting another issuer. The issuer map is copied so that readers never need a lock
+func (keyManager *KeyManager) AddIssuer(issuerSource IssuerSource) {
+	keys := &issuerKeys{issuer: issuerSource.Issuer, source: issuerSource.Source}
+
+	maxAge, err := keys.refresh()
+	if err != nil {
+		fmt.Println("Error fetching JWKs for", keys.issuer.Issuer, *err)
+	}
+
+	for {
+		current := keyManager.issuers.Load()
+		updated := make(map[string]*issuerKeys, len(*current)+1)
+		for iss, existingKeys := range *current {
+			updated[iss] = existingKeys
+		}
+		updated[keys.issuer.Issuer] = keys
+
+		if keyManager.issuers.CompareAndSwap(current, &updated) {
+			break
+		}
+	}
+
+	go keyManager.refreshLoop(keys, maxAge)
+}
+
+func (keyManager *KeyManager) Close() {
+	keyManager.stopOnce.Do(func() {
+		close(keyManager.stop)
+	})
+}
+
+func (keyManager *KeyManager) GetIssuer(iss string) *interfaces.TrustedIssuer {
+	if keys, found := (*keyManager.issuers.Load())[iss]; found {
+		return &keys.issuer
+	}
+	return nil
+}
+
+// Returns the key set of the issuer. An unknown kid triggers a single rate-limited refetch
+func (keyManager *KeyManager) GetKeySet(iss string, kid string) (jwk.Set, *string) {
+	keys, found := (*keyManager.issuers.Load())[iss]
+	if !found {
+		return nil, &constants.ERROR_UNTRUSTED_ISSUER
+	}
+
+	jwkSet := keys.jwkSet.Load()
+	if jwkSet != nil {
+		if _, found := (*jwkSet).LookupKeyID(kid); found {
+			return *jwkSet, nil
+		}
+	}
+
+	keys.refreshRateLimited()
+
+	jwkSet = keys.jwkSet.Load()
+	if jwkSet == nil {
+		return nil, &constants.ERROR_FETCH_JWK
+	}
+	return *jwkSet, nil
+}
+
+// Refetches unless the keys were fetched within JWKS_KID_MISS_REFETCH_INTERVAL
+func (keys *issuerKeys) refreshRateLimited() {
+	keys.refreshLock.Lock()
+	defer keys.refreshLock.Unlock()
+	if time.Since(time.Unix(0, keys.lastFetch.Load())) >= constants.JWKS_KID_MISS_REFETCH_INTERVAL {
+		keys.refresh()
+	}
+}
+
+// Refetches the keys of every issuer. Reachable from a public route, so it is rate limited like a kid miss
+func (keyManager *KeyManager) RefreshAll() {
+	for _, keys := range *keyManager.issuers.Load() {
+		keys.refreshRateLimited()
+	}
+}
+
+func (keyManager *KeyManager) refreshLoop(keys *issuerKeys, maxAge time.Duration) {
+	for {
+		select {
+		case <-keyManager.stop:
+			return
+		case <-time.After(maxAge):
+		}
+
+		keys.refreshLock.Lock()
+		newMaxAge, err := keys.refresh()
+		keys.refreshLock.Unlock()
+
+		if err != nil {
+			fmt.Println("Error refreshing JWKs for", keys.issuer.Issuer, *err)
+		}
+		maxAge = newMaxAge
+	}
+}
+
+// Swaps in a freshly fetched set and tells when it should be fetched again
+func (keys *issuerKeys) refresh() (time.Duration, *string) {
+	keys.lastFetch.Store(time.Now().UnixNano())
+
+	jwkSet, maxAge, err := keys.source.Fetch()
+	if err != nil {
+		return constants.JWKS_MIN_REFRESH_INTERVAL, err
+	}
+
+	keys.jwkSet.Store(&jwkSet)
+	return maxAge, nil
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// Serves whichever set the test put in last and counts the fetches
+type countingJWKSource struct {
+	mutex   sync.Mutex
+	set     jwk.Set
+	fetches int
+}
+
+func (source *countingJWKSource) Fetch() (jwk.Set, time.Duration, *string) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	source.fetches++
+	if source.set == nil {
+		return nil, 0, &constants.ERROR_FETCH_JWK
+	}
+	return source.set, constants.JWKS_MAX_REFRESH_INTERVAL, nil
+}
+
+func (source *countingJWKSource) rotate(set jwk.Set) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	source.set = set
+}
+
+func (source *countingJWKSource) fetchCount() int {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	return source.fetches
+}
+
+// Pretends the last fetch was long enough ago for a kid miss to refetch again
+func expireLastFetch(keyManager *KeyManager, iss string) {
+	(*keyManager.issuers.Load())[iss].lastFetch.Store(time.Now().Add(-constants.JWKS_KID_MISS_REFETCH_INTERVAL).UnixNano())
+}
+
+func TestKeyManagerKidMiss(t *testing.T) {
+	_, oldSet := newTestSigningKey(t, "old")
+	_, newSet := newTestSigningKey(t, "new")
+	source := &countingJWKSource{set: oldSet}
+	keyManager := newTestKeyManager(t, IssuerSource{
+		Issuer: interfaces.TrustedIssuer{Issuer: testIssuer, Audience: testAudience},
+		Source: source,
+	})
+	source.rotate(newSet)
+
+	tests := []struct {
+		name      string
+		kid       string
+		expire    bool
+		fetches   int
+		wantFound bool
+	}{
+		{"known kid is served from memory", "old", false, 1, true},
+		{"kid miss right after a fetch is rate limited", "new", false, 1, false},
+		{"kid miss refetches once the interval passed", "new", true, 2, true},
+		{"rotated key is kept", "new", false, 2, true},
+		{"unknown kid does not refetch again", "missing", false, 2, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.expire {
+				expireLastFetch(keyManager, testIssuer)
+			}
+			jwkSet, err := keyManager.GetKeySet(testIssuer, test.kid)
+			if err != nil {
+				t.Fatalf("GetKeySet() error = %s", *err)
+			}
+			if _, found := jwkSet.LookupKeyID(test.kid); found != test.wantFound {
+				t.Errorf("GetKeySet() has %s = %v, want %v", test.kid, found, test.wantFound)
+			}
+			if fetches := source.fetchCount(); fetches != test.fetches {
+				t.Errorf("fetched %d times, want %d", fetches, test.fetches)
+			}
+		})
+	}
+}
+
+func TestKeyManagerRefreshAllIsRateLimited(t *testing.T) {
+	_, publicSet := newTestSigningKey(t, "key")
+	source := &countingJWKSource{set: publicSet}
+	keyManager := newTestKeyManager(t, IssuerSource{
+		Issuer: interfaces.TrustedIssuer{Issuer: testIssuer, Audience: testAudience},
+		Source: source,
+	})
+
+	for idx := 0; idx < 5; idx++ {
+		keyManager.RefreshAll()
+	}
+	if fetches := source.fetchCount(); fetches != 1 {
+		t.Errorf("fetched %d times, want 1", fetches)
+	}
+
+	expireLastFetch(keyManager, testIssuer)
+	keyManager.RefreshAll()
+	if fetches := source.fetchCount(); fetches != 2 {
+		t.Errorf("fetched %d times after the interval, want 2", fetches)
+	}
+}
+
+func TestKeyManagerGetKeySetErrors(t *testing.T) {
+	source := &countingJWKSource{}
+	keyManager := newTestKeyManager(t, IssuerSource{
+		Issuer: interfaces.TrustedIssuer{Issuer: testIssuer, Audience: testAudience},
+		Source: source,
+	})
+
+	tests := []struct {
+		name string
+		iss  string
+		err  string
+	}{
+		{"untrusted issuer", "https://evil.example.com", constants.ERROR_UNTRUSTED_ISSUER},
+		{"keys never fetched", testIssuer, constants.ERROR_FETCH_JWK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := keyManager.GetKeySet(test.iss, "key"); err == nil || *err != test.err {
+				t.Errorf("GetKeySet() error = %v, want %s", err, test.err)
+			}
+		})
+	}
+}
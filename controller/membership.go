@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/models/company"
+	db "github.com/FrosTiK-SD/mongik/db"
+	models "github.com/FrosTiK-SD/mongik/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Rejects windows that are already over or end before they start
+func ValidateMembershipWindow(validFrom *primitive.DateTime, validUntil *primitive.DateTime) *string {
+	if validUntil == nil {
+		return nil
+	}
+	if !validUntil.Time().After(time.Now()) || (validFrom != nil && !validUntil.Time().After(validFrom.Time())) {
+		return &constants.ERROR_INVALID_MEMBERSHIP_WINDOW
+	}
+	return nil
+}
+
+func membershipFilter(students []primitive.ObjectID, groups []primitive.ObjectID) bson.M {
+	return bson.M{
+		"student": bson.M{"$in": students},
+		"group":   bson.M{"$in": groups},
+	}
+}
+
+// Replaces the memberships of the students in the groups, a push without bounds makes them permanent
+func SetMemberships(mongikClient *models.Mongik, students []primitive.ObjectID, groups []primitive.ObjectID, validFrom *primitive.DateTime, validUntil *primitive.DateTime) error {
+	if _, err := db.DeleteMany(mongikClient, constants.DB, constants.COLLECTION_MEMBERSHIP, membershipFilter(students, groups)); err != nil {
+		return err
+	}
+	if validFrom == nil && validUntil == nil {
+		return nil
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	var memberships []model.Membership
+	for _, student := range students {
+		for _, group := range groups {
+			memberships = append(memberships, model.Membership{
+				Id:         primitive.NewObjectID(),
+				Student:    student,
+				Group:      group,
+				ValidFrom:  validFrom,
+				ValidUntil: validUntil,
+				CreatedAt:  now,
+			})
+		}
+	}
+	if len(memberships) == 0 {
+		return nil
+	}
+
+	_, err := db.InsertMany(mongikClient, constants.DB, constants.COLLECTION_MEMBERSHIP, memberships)
+	return err
+}
+
+func DeleteMemberships(mongikClient *models.Mongik, students []primitive.ObjectID, groups []primitive.ObjectID) error {
+	_, err := db.DeleteMany(mongikClient, constants.DB, constants.COLLECTION_MEMBERSHIP, membershipFilter(students, groups))
+	return err
+}
+
+// Drops the groups whose membership has not started yet or has ended, so that they grant no roles
+func ApplyMemberships(mongikClient *models.Mongik, student *model.StudentPopulated, noCache bool) error {
+	if student.Id.IsZero() || len(student.GroupDetails) == 0 {
+		return nil
+	}
+
+	memberships, err := db.Aggregate[model.Membership](mongikClient, constants.DB, constants.COLLECTION_MEMBERSHIP, []bson.M{{
+		"$match": bson.M{"student": student.Id},
+	}}, noCache)
+	if err != nil || len(memberships) == 0 {
+		return err
+	}
+
+	student.GroupDetails = activeGroups(student.GroupDetails, memberships, time.Now())
+	return nil
+}
+
+// The groups without a membership or with one that is active at now
+func activeGroups(groups []company.Group, memberships []model.Membership, now time.Time) []company.Group {
+	inactive := map[primitive.ObjectID]struct{}{}
+	for idx := range memberships {
+		if !memberships[idx].IsActive(now) {
+			inactive[memberships[idx].Group] = struct{}{}
+		}
+	}
+	if len(inactive) == 0 {
+		return groups
+	}
+
+	active := make([]company.Group, 0, len(groups))
+	for _, group := range groups {
+		if _, found := inactive[group.ID]; !found {
+			active = append(active, group)
+		}
+	}
+	return active
+}
+
+// Removes the students from the groups of every membership past its validUntil and returns those memberships
+func SweepExpiredMemberships(mongikClient *models.Mongik) ([]model.Membership, error) {
+	membershipCollection := mongikClient.MongoClient.Database(constants.DB).Collection(constants.COLLECTION_MEMBERSHIP)
+	cursor, err := membershipCollection.Find(context.Background(), bson.M{
+		"validUntil": bson.M{"$lte": primitive.NewDateTimeFromTime(time.Now())},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []model.Membership
+	if err := cursor.All(context.Background(), &expired); err != nil {
+		return nil, err
+	}
+
+	var swept []model.Membership
+	for _, membership := range expired {
+		if _, err := db.UpdateMany[model.StudentPopulated](mongikClient, constants.DB, constants.COLLECTION_STUDENT, bson.M{
+			"_id": membership.Student,
+		}, bson.M{
+			"$pull": bson.M{"groups": membership.Group},
+		}); err != nil {
+			return swept, err
+		}
+		if _, err := db.DeleteMany(mongikClient, constants.DB, constants.COLLECTION_MEMBERSHIP, bson.M{"_id": membership.Id}); err != nil {
+			return swept, err
+		}
+		swept = append(swept, membership)
+	}
+
+	return swept, nil
+}
+
+// Session tokens minted while the memberships were active still carry their groups
+func RevokeMemberships(revocationStore *RevocationStore, memberships []model.Membership, reason string) *string {
+	for _, membership := range memberships {
+		if err := RevokeGroups(revocationStore, []primitive.ObjectID{membership.Student}, []primitive.ObjectID{membership.Group}, "", reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
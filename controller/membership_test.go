@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/models/company"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func dateTimeIn(duration time.Duration) *primitive.DateTime {
+	dateTime := primitive.NewDateTimeFromTime(time.Now().Add(duration))
+	return &dateTime
+}
+
+func TestValidateMembershipWindow(t *testing.T) {
+	tests := []struct {
+		name       string
+		validFrom  *primitive.DateTime
+		validUntil *primitive.DateTime
+		valid      bool
+	}{
+		{"permanent", nil, nil, true},
+		{"starts later", dateTimeIn(time.Hour), nil, true},
+		{"ends later", nil, dateTimeIn(time.Hour), true},
+		{"window", dateTimeIn(time.Hour), dateTimeIn(2 * time.Hour), true},
+		{"ended", nil, dateTimeIn(-time.Hour), false},
+		{"ends before it starts", dateTimeIn(2 * time.Hour), dateTimeIn(time.Hour), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateMembershipWindow(test.validFrom, test.validUntil)
+			if test.valid && err != nil {
+				t.Errorf("ValidateMembershipWindow() error = %s, want none", *err)
+			}
+			if !test.valid && (err == nil || *err != constants.ERROR_INVALID_MEMBERSHIP_WINDOW) {
+				t.Errorf("ValidateMembershipWindow() error = %v, want %s", err, constants.ERROR_INVALID_MEMBERSHIP_WINDOW)
+			}
+		})
+	}
+}
+
+func TestActiveGroups(t *testing.T) {
+	group := company.Group{ID: primitive.NewObjectID()}
+	other := company.Group{ID: primitive.NewObjectID()}
+
+	tests := []struct {
+		name       string
+		membership model.Membership
+		want       []company.Group
+	}{
+		{"no bounds", model.Membership{Group: group.ID}, []company.Group{group, other}},
+		{"active window", model.Membership{Group: group.ID, ValidFrom: dateTimeIn(-time.Hour), ValidUntil: dateTimeIn(time.Hour)}, []company.Group{group, other}},
+		{"not started", model.Membership{Group: group.ID, ValidFrom: dateTimeIn(time.Hour)}, []company.Group{other}},
+		{"ended", model.Membership{Group: group.ID, ValidUntil: dateTimeIn(-time.Minute)}, []company.Group{other}},
+		{"membership of a group not held", model.Membership{Group: primitive.NewObjectID(), ValidUntil: dateTimeIn(-time.Minute)}, []company.Group{group, other}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := activeGroups([]company.Group{group, other}, []model.Membership{test.membership}, time.Now())
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("activeGroups() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRevokeMemberships(t *testing.T) {
+	revocationStore := NewRevocationStore(nil)
+	swept := model.Membership{Student: primitive.NewObjectID(), Group: primitive.NewObjectID()}
+	kept := primitive.NewObjectID()
+	if err := RevokeMemberships(revocationStore, []model.Membership{swept}, "Group membership expired"); err != nil {
+		t.Fatalf("RevokeMemberships() error = %s", *err)
+	}
+
+	iat := int(time.Now().Add(-time.Minute).Unix())
+	tests := []struct {
+		name    string
+		token   interfaces.Token
+		revoked bool
+	}{
+		{"token with the swept group", interfaces.Token{Sub: swept.Student.Hex(), Groups: []string{kept.Hex(), swept.Group.Hex()}, Iat: iat}, true},
+		{"token with other groups", interfaces.Token{Sub: swept.Student.Hex(), Groups: []string{kept.Hex()}, Iat: iat}, false},
+		{"token of another member of the group", interfaces.Token{Sub: primitive.NewObjectID().Hex(), Groups: []string{swept.Group.Hex()}, Iat: iat}, false},
+		{"token minted after the sweep", interfaces.Token{Sub: swept.Student.Hex(), Groups: []string{swept.Group.Hex()}, Iat: int(time.Now().Add(time.Minute).Unix())}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			revoked, err := revocationStore.IsRevoked(&test.token)
+			if err != nil {
+				t.Fatalf("IsRevoked() error = %s", *err)
+			}
+			if revoked != test.revoked {
+				t.Errorf("IsRevoked() = %v, want %v", revoked, test.revoked)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/models/company"
+	mongikModels "github.com/FrosTiK-SD/mongik/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Effective roles of the principal, the groups they come from and the routes it may call
+func GetPermissions(mongikClient *mongikModels.Mongik, principal model.Principal, noCache bool) (*interfaces.PermissionsResponse, error) {
+	var groups []company.Group
+	switch principal := principal.(type) {
+	case *model.StudentPopulated:
+		groups = principal.GroupDetails
+	case *model.RecruiterModelPopulated:
+		groups = principal.GroupDetails
+	}
+
+	permissions := interfaces.PermissionsResponse{
+		Id:     principal.PrincipalID(),
+		Kind:   principal.PrincipalKind(),
+		Email:  principal.PrincipalEmail(),
+		Roles:  principal.RoleSet().List(),
+		Groups: []interfaces.PermissionsGroup{},
+		Routes: GetRoutePolicy().AllowedRoutes(principal),
+	}
+	if len(groups) == 0 {
+		return &permissions, nil
+	}
+
+	groupIds := make([]primitive.ObjectID, 0, len(groups))
+	for _, group := range groups {
+		groupIds = append(groupIds, group.ID)
+	}
+	groupScopes, err := GetGroupScopes(mongikClient, groupIds, noCache)
+	if err != nil {
+		return nil, err
+	}
+
+	// Principals from session tokens only know their group ids, so the groups are read from the collection
+	for _, groupScope := range groupScopes {
+		permissions.Groups = append(permissions.Groups, interfaces.PermissionsGroup{
+			Id:    groupScope.Id,
+			Name:  groupScope.Name,
+			Roles: groupScope.Roles,
+			Scope: groupScope.Scope,
+		})
+	}
+
+	return &permissions, nil
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGetPermissions(t *testing.T) {
+	previous := GetRoutePolicy()
+	SetRoutePolicy(loadTestRoutePolicy(t))
+	t.Cleanup(func() { SetRoutePolicy(previous) })
+
+	student := testStudent(constants.ROLE_STUDENT, constants.ROLE_TPR)
+	student.Id = primitive.NewObjectID()
+	student.InstituteEmail = "someone.cse20@iitbhu.ac.in"
+	apiKey := &model.APIKey{Id: primitive.NewObjectID()}
+	apiKey.SetResolvedRoles([]string{constants.ROLE_GROUP_EDIT})
+
+	tests := []struct {
+		name      string
+		principal model.Principal
+		kind      model.PrincipalKind
+		email     string
+		roles     []string
+		routes    []string
+	}{
+		{"student", student, model.PRINCIPAL_STUDENT, student.InstituteEmail, []string{constants.ROLE_STUDENT, constants.ROLE_TPR}, []string{
+			"GET /api/token/*/verify", "GET /api/group/:id", "GET /api/student/*",
+		}},
+		{"api key", apiKey, model.PRINCIPAL_API_CLIENT, "", []string{constants.ROLE_GROUP_EDIT}, []string{
+			"GET /api/token/*/verify", "GET /api/student/*",
+		}},
+	}
+
+	// Principals without groups never need the database
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			permissions, err := GetPermissions(nil, test.principal, false)
+			if err != nil {
+				t.Fatalf("GetPermissions() error = %v", err)
+			}
+			if permissions.Id != test.principal.PrincipalID() || permissions.Kind != test.kind || permissions.Email != test.email {
+				t.Errorf("GetPermissions() = %s %s %s, want %s %s %s", permissions.Id.Hex(), permissions.Kind, permissions.Email, test.principal.PrincipalID().Hex(), test.kind, test.email)
+			}
+			if !reflect.DeepEqual(permissions.Roles, test.roles) {
+				t.Errorf("Roles = %v, want %v", permissions.Roles, test.roles)
+			}
+			if len(permissions.Groups) != 0 {
+				t.Errorf("Groups = %v, want none", permissions.Groups)
+			}
+
+			routes := []string{}
+			for _, route := range permissions.Routes {
+				routes = append(routes, route.Method+" "+route.Path)
+			}
+			if !reflect.DeepEqual(routes, test.routes) {
+				t.Errorf("Routes = %v, want %v", routes, test.routes)
+			}
+		})
+	}
+}
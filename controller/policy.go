@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
+)
+
+type routePolicyRule struct {
+	interfaces.RoutePolicyRule
+	expression util.RoleExpression
+}
+
+// Rules are tried in file order and the first match wins. Routes matching no rule are denied
+type RoutePolicy struct {
+	Source   string                       `json:"source"`
+	LoadedAt time.Time                    `json:"loadedAt"`
+	Rules    []interfaces.RoutePolicyRule `json:"rules"`
+
+	rules   []routePolicyRule
+	modTime time.Time
+}
+
+var currentRoutePolicy atomic.Pointer[RoutePolicy]
+
+func LoadRoutePolicy(configPath string) (*RoutePolicy, error) {
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return nil, err
+	}
+	configBytes, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var policyFile interfaces.RoutePolicyFile
+	if err := json.Unmarshal(configBytes, &policyFile); err != nil {
+		return nil, err
+	}
+
+	policy := &RoutePolicy{
+		Source:   configPath,
+		LoadedAt: time.Now(),
+		modTime:  info.ModTime(),
+	}
+	for idx, rule := range policyFile.Rules {
+		rule.Method = strings.ToUpper(rule.Method)
+		if rule.Method == "" || rule.Path == "" {
+			return nil, fmt.Errorf("rule %d is missing the method or path", idx)
+		}
+		if _, err := path.Match(rule.Path, rule.Path); err != nil {
+			return nil, fmt.Errorf("rule %d has an invalid path %s: %w", idx, rule.Path, err)
+		}
+
+		var expression util.RoleExpression
+		switch rule.Principal {
+		case interfaces.POLICY_PUBLIC:
+			if rule.Roles != nil || rule.RecentAuth {
+				return nil, fmt.Errorf("public rule %s %s cannot require roles or a recent sign-in", rule.Method, rule.Path)
+			}
+		case interfaces.POLICY_STUDENT, interfaces.POLICY_PRINCIPAL, interfaces.POLICY_RECRUITER:
+			if rule.Roles != nil {
+				if expression, err = rule.Roles.Build(); err != nil {
+					return nil, fmt.Errorf("rule %s %s: %w", rule.Method, rule.Path, err)
+				}
+				rule.Expression = expression.String()
+			}
+		default:
+			return nil, fmt.Errorf("rule %s %s has an unknown principal %q", rule.Method, rule.Path, rule.Principal)
+		}
+
+		policy.Rules = append(policy.Rules, rule)
+		policy.rules = append(policy.rules, routePolicyRule{RoutePolicyRule: rule, expression: expression})
+	}
+
+	return policy, nil
+}
+
+// Returns the rule for a Gin route pattern along with its role expression, which is nil when any principal is allowed
+func (policy *RoutePolicy) Match(method string, route string) (*interfaces.RoutePolicyRule, util.RoleExpression) {
+	for idx := range policy.rules {
+		rule := &policy.rules[idx]
+		if rule.Method != "*" && rule.Method != method {
+			continue
+		}
+		if matched, _ := path.Match(rule.Path, route); matched {
+			return &rule.RoutePolicyRule, rule.expression
+		}
+	}
+	return nil, nil
+}
+
+// Never nil, an empty policy denies every route
+func GetRoutePolicy() *RoutePolicy {
+	if policy := currentRoutePolicy.Load(); policy != nil {
+		return policy
+	}
+	return &RoutePolicy{}
+}
+
+// Replaces the enforced policy, for policies loaded from elsewhere than ROUTE_POLICY_CONFIG
+func SetRoutePolicy(policy *RoutePolicy) {
+	currentRoutePolicy.Store(policy)
+}
+
+func GetRoutePolicyPath() string {
+	if configPath := os.Getenv(constants.ROUTE_POLICY_CONFIG); configPath != "" {
+		return configPath
+	}
+	return constants.DEFAULT_ROUTE_POLICY_CONFIG
+}
+
+var startRoutePolicyRefreshOnce sync.Once
+var startRoutePolicyRefreshErr error
+
+// Loads the policy in ROUTE_POLICY_CONFIG and reloads it whenever the file changes.
+// The first load has to succeed, as the empty policy would deny every route. Later a file
+// that fails to load leaves the previous policy in place
+func StartRoutePolicyRefresh() error {
+	startRoutePolicyRefreshOnce.Do(func() {
+		// Resolved once so that the file is still found if the working directory changes
+		configPath, err := filepath.Abs(GetRoutePolicyPath())
+		if err != nil {
+			startRoutePolicyRefreshErr = err
+			return
+		}
+
+		policy, err := LoadRoutePolicy(configPath)
+		if err != nil {
+			startRoutePolicyRefreshErr = fmt.Errorf("loading route policy (set %s to its path): %w", constants.ROUTE_POLICY_CONFIG, err)
+			return
+		}
+		SetRoutePolicy(policy)
+		fmt.Println("Loaded route policy with", len(policy.Rules), "rules from", configPath)
+
+		reload := func() {
+			info, err := os.Stat(configPath)
+			if err == nil && info.ModTime().Equal(GetRoutePolicy().modTime) {
+				return
+			}
+
+			policy, err := LoadRoutePolicy(configPath)
+			if err != nil {
+				fmt.Println("Error loading route policy:", err)
+				return
+			}
+			SetRoutePolicy(policy)
+			fmt.Println("Loaded route policy with", len(policy.Rules), "rules from", configPath)
+		}
+
+		go func() {
+			for range time.Tick(constants.ROUTE_POLICY_REFRESH_INTERVAL) {
+				reload()
+			}
+		}()
+	})
+	return startRoutePolicyRefreshErr
+}
+
+// Whether the principal passes the verification and role check of the rule
+func routePolicyRuleAllows(rule *routePolicyRule, principal model.Principal) bool {
+	switch rule.Principal {
+	case interfaces.POLICY_PUBLIC:
+		return true
+	case interfaces.POLICY_STUDENT:
+		if principal.PrincipalKind() != model.PRINCIPAL_STUDENT {
+			return false
+		}
+	case interfaces.POLICY_PRINCIPAL:
+		if principal.PrincipalKind() == model.PRINCIPAL_RECRUITER {
+			return false
+		}
+	case interfaces.POLICY_RECRUITER:
+		if principal.PrincipalKind() != model.PRINCIPAL_RECRUITER {
+			return false
+		}
+	}
+
+	if rule.expression == nil {
+		return true
+	}
+	met, _ := rule.expression.Evaluate(principal.RoleSet())
+	return met
+}
+
+// Routes of the policy the principal is let through on
+func (policy *RoutePolicy) AllowedRoutes(principal model.Principal) []interfaces.AllowedRoute {
+	allowedRoutes := []interfaces.AllowedRoute{}
+	for idx := range policy.rules {
+		rule := &policy.rules[idx]
+		if routePolicyRuleAllows(rule, principal) {
+			allowedRoutes = append(allowedRoutes, interfaces.AllowedRoute{
+				Method:     rule.Method,
+				Path:       rule.Path,
+				RecentAuth: rule.RecentAuth,
+			})
+		}
+	}
+	return allowedRoutes
+}
@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+)
+
+const testRoutePolicy = `{
+	"rules": [
+		{"method": "GET", "path": "/api/token/*/verify", "principal": "public"},
+		{"method": "delete", "path": "/api/group/:id", "principal": "student", "roles": "ADMIN", "recentAuth": true},
+		{"method": "GET", "path": "/api/group/:id", "principal": "student", "roles": {"anyOf": ["GROUP_READ", "TPR"]}},
+		{"method": "*", "path": "/api/group/*", "principal": "student", "roles": "GROUP_EDIT"},
+		{"method": "GET", "path": "/api/student/*", "principal": "principal"},
+		{"method": "GET", "path": "/api/recruiter/me", "principal": "recruiter"}
+	]
+}`
+
+func writeTestConfig(t *testing.T, config string) string {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(configPath, []byte(config), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return configPath
+}
+
+func loadTestRoutePolicy(t *testing.T) *RoutePolicy {
+	t.Helper()
+	policy, err := LoadRoutePolicy(writeTestConfig(t, testRoutePolicy))
+	if err != nil {
+		t.Fatalf("LoadRoutePolicy() error = %v", err)
+	}
+	return policy
+}
+
+func TestLoadRoutePolicy(t *testing.T) {
+	policy := loadTestRoutePolicy(t)
+
+	if len(policy.Rules) != 6 {
+		t.Fatalf("LoadRoutePolicy() loaded %d rules, want 6", len(policy.Rules))
+	}
+	if policy.Rules[1].Method != "DELETE" {
+		t.Errorf("Method = %q, want it upper cased to DELETE", policy.Rules[1].Method)
+	}
+	if policy.Rules[2].Expression != "AnyOf(GROUP_READ, TPR)" {
+		t.Errorf("Expression = %q, want AnyOf(GROUP_READ, TPR)", policy.Rules[2].Expression)
+	}
+}
+
+func TestLoadRoutePolicyErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"invalid json", `{"rules": [`},
+		{"missing method", `{"rules": [{"path": "/api/group", "principal": "student"}]}`},
+		{"missing path", `{"rules": [{"method": "GET", "principal": "student"}]}`},
+		{"invalid path", `{"rules": [{"method": "GET", "path": "/api/[group", "principal": "student"}]}`},
+		{"unknown principal", `{"rules": [{"method": "GET", "path": "/api/group", "principal": "admin"}]}`},
+		{"public rule with roles", `{"rules": [{"method": "GET", "path": "/api/group", "principal": "public", "roles": "ADMIN"}]}`},
+		{"public rule with recent auth", `{"rules": [{"method": "GET", "path": "/api/group", "principal": "public", "recentAuth": true}]}`},
+		{"invalid role expression", `{"rules": [{"method": "GET", "path": "/api/group", "principal": "student", "roles": {"allOf": []}}]}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := LoadRoutePolicy(writeTestConfig(t, test.config)); err == nil {
+				t.Errorf("LoadRoutePolicy() succeeded, want an error")
+			}
+		})
+	}
+
+	if _, err := LoadRoutePolicy(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("LoadRoutePolicy() of a missing file succeeded, want an error")
+	}
+}
+
+func TestRoutePolicyMatch(t *testing.T) {
+	policy := loadTestRoutePolicy(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		route      string
+		path       string
+		principal  interfaces.PolicyPrincipal
+		expression string
+	}{
+		{"glob segment", "GET", "/api/token/student/verify", "/api/token/*/verify", interfaces.POLICY_PUBLIC, ""},
+		{"first match wins over a later glob", "DELETE", "/api/group/:id", "/api/group/:id", interfaces.POLICY_STUDENT, "ADMIN"},
+		{"method picks between rules of the same path", "GET", "/api/group/:id", "/api/group/:id", interfaces.POLICY_STUDENT, "AnyOf(GROUP_READ, TPR)"},
+		{"any method", "PUT", "/api/group/:id", "/api/group/*", interfaces.POLICY_STUDENT, "GROUP_EDIT"},
+		{"rule without roles", "GET", "/api/student/all", "/api/student/*", interfaces.POLICY_PRINCIPAL, ""},
+		{"glob does not cross segments", "GET", "/api/student/tpr/all", "", "", ""},
+		{"method not allowed", "POST", "/api/student/all", "", "", ""},
+		{"unmatched route", "GET", "/api/domain", "", "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule, expression := policy.Match(test.method, test.route)
+			if test.path == "" {
+				if rule != nil {
+					t.Fatalf("Match() = %s %s, want no rule", rule.Method, rule.Path)
+				}
+				return
+			}
+			if rule == nil {
+				t.Fatalf("Match() found no rule, want %s", test.path)
+			}
+			if rule.Path != test.path || rule.Principal != test.principal {
+				t.Errorf("Match() = %s for %s, want %s for %s", rule.Path, rule.Principal, test.path, test.principal)
+			}
+
+			got := ""
+			if expression != nil {
+				got = expression.String()
+			}
+			if got != test.expression {
+				t.Errorf("Match() expression = %q, want %q", got, test.expression)
+			}
+		})
+	}
+}
+
+func TestEmptyRoutePolicyDeniesEverything(t *testing.T) {
+	if rule, _ := (&RoutePolicy{}).Match("GET", "/api/token/student/verify"); rule != nil {
+		t.Errorf("Match() = %s %s, want no rule", rule.Method, rule.Path)
+	}
+}
+
+func TestRoutePolicyAllowedRoutes(t *testing.T) {
+	policy := loadTestRoutePolicy(t)
+
+	student := &model.StudentPopulated{}
+	student.SetResolvedRoles([]string{"STUDENT"})
+	tpr := &model.StudentPopulated{}
+	tpr.SetResolvedRoles([]string{"STUDENT", "TPR"})
+	admin := &model.StudentPopulated{}
+	admin.SetResolvedRoles([]string{"STUDENT", "ADMIN", "GROUP_EDIT", "GROUP_READ"})
+	apiKey := &model.APIKey{}
+	apiKey.SetResolvedRoles([]string{"GROUP_EDIT"})
+	recruiter := &model.RecruiterModelPopulated{}
+	recruiter.SetResolvedRoles([]string{"recruiter", "ADMIN"})
+
+	tests := []struct {
+		name      string
+		principal model.Principal
+		want      []string
+	}{
+		{"student", student, []string{"GET /api/token/*/verify", "GET /api/student/*"}},
+		{"role expression", tpr, []string{"GET /api/token/*/verify", "GET /api/group/:id", "GET /api/student/*"}},
+		{"admin", admin, []string{
+			"GET /api/token/*/verify", "DELETE /api/group/:id", "GET /api/group/:id", "* /api/group/*", "GET /api/student/*",
+		}},
+		{"api keys are not students", apiKey, []string{"GET /api/token/*/verify", "GET /api/student/*"}},
+		{"recruiters only get recruiter rules", recruiter, []string{"GET /api/token/*/verify", "GET /api/recruiter/me"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := []string{}
+			for _, route := range policy.AllowedRoutes(test.principal) {
+				got = append(got, route.Method+" "+route.Path)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("AllowedRoutes() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"regexp"
+
 	"github.com/FrosTiK-SD/auth/constants"
 	"github.com/FrosTiK-SD/auth/model"
 	db "github.com/FrosTiK-SD/mongik/db"
@@ -13,7 +15,7 @@ func GetRecruiterByEmail(mongikClient *models.Mongik, email *string, role *strin
 	recruiterPopulated, _ = db.AggregateOne[model.RecruiterModelPopulated](mongikClient, constants.DB, constants.COLLECTION_RECRUITER, []bson.M{{
 		"$match": bson.M{
 			"email": bson.M{
-				"$regex":   "^" + *email + "$",
+				"$regex":   "^" + regexp.QuoteMeta(*email) + "$",
 				"$options": "i",
 			},
 		},
@@ -25,10 +27,11 @@ func GetRecruiterByEmail(mongikClient *models.Mongik, email *string, role *strin
 			"as":           "groups",
 		},
 	}}, noCache)
+	recruiterPopulated.ResolveRoleSet()
 
-	// if !util.CheckRoleExists(&recruiterPopulated.GroupDetails, *role) {
-	// 	return nil, &constants.ERROR_NOT_A_RECRUITER
-	// }
+	if recruiterPopulated.ID.IsZero() || !recruiterPopulated.RoleSet().Has(*role) {
+		return nil, &constants.ERROR_NOT_A_RECRUITER
+	}
 
 	return &recruiterPopulated, nil
 }
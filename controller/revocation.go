@@ -0,0 +1,332 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	db "github.com/FrosTiK-SD/mongik/db"
+	models "github.com/FrosTiK-SD/mongik/models"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Revocations live in the Redis that mongik already holds so every instance sees them.
+// Without Redis they are only kept in the memory of this process
+type RevocationStore struct {
+	redisClient *redis.Client
+	lock        sync.RWMutex
+	local       map[string]model.Revocation
+}
+
+func NewRevocationStore(mongikClient *models.Mongik) *RevocationStore {
+	revocationStore := &RevocationStore{
+		local: map[string]model.Revocation{},
+	}
+	if mongikClient != nil {
+		revocationStore.redisClient = mongikClient.RedisClient
+	}
+	return revocationStore
+}
+
+// Tokens without a jti are identified by the hash of the raw token
+func GetTokenID(jti string, rawToken string) string {
+	if jti != "" {
+		return jti
+	}
+	hash := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(hash[:])
+}
+
+func revocationKey(revocationType model.RevocationType, subject string) string {
+	return constants.REVOCATION_KEY_PREFIX + string(revocationType) + ":" + subject
+}
+
+func authTimeSubject(email string, authTime int) string {
+	return email + ":" + strconv.Itoa(authTime)
+}
+
+func groupSubject(student string, group string) string {
+	return student + ":" + group
+}
+
+func (revocationStore *RevocationStore) keysOf(revocation *model.Revocation) []string {
+	switch revocation.Type {
+	case model.REVOCATION_TOKEN:
+		return []string{revocationKey(model.REVOCATION_TOKEN, revocation.TokenID)}
+	case model.REVOCATION_USER:
+		var keys []string
+		for _, email := range getAliasEmailList(revocation.Email) {
+			keys = append(keys, revocationKey(model.REVOCATION_USER, email))
+		}
+		return keys
+	case model.REVOCATION_AUTH_TIME:
+		var keys []string
+		for _, email := range getAliasEmailList(revocation.Email) {
+			keys = append(keys, revocationKey(model.REVOCATION_AUTH_TIME, authTimeSubject(email, revocation.AuthTime)))
+		}
+		return keys
+	case model.REVOCATION_GROUP:
+		if revocation.Student == "" || revocation.Group == "" {
+			return nil
+		}
+		return []string{revocationKey(model.REVOCATION_GROUP, groupSubject(revocation.Student, revocation.Group))}
+	}
+	return nil
+}
+
+// Stores the revocation until no token it covers can still be valid
+func (revocationStore *RevocationStore) Revoke(revocation *model.Revocation) *string {
+	revocation.Email = strings.ToLower(revocation.Email)
+	if revocation.RevokedAt.IsZero() {
+		revocation.RevokedAt = time.Now()
+	}
+	if revocation.ExpiresAt.IsZero() {
+		switch revocation.Type {
+		case model.REVOCATION_AUTH_TIME:
+			revocation.ExpiresAt = revocation.RevokedAt.Add(constants.REVOCATION_AUTH_TIME_RETENTION)
+		case model.REVOCATION_GROUP:
+			revocation.ExpiresAt = revocation.RevokedAt.Add(constants.SESSION_ACCESS_TOKEN_TTL)
+		default:
+			revocation.ExpiresAt = revocation.RevokedAt.Add(constants.REVOCATION_RETENTION)
+		}
+	}
+
+	keys := revocationStore.keysOf(revocation)
+	if len(keys) == 0 {
+		return &constants.ERROR_INVALID_REVOCATION
+	}
+
+	if revocationStore.redisClient == nil {
+		revocationStore.lock.Lock()
+		for _, key := range keys {
+			revocationStore.local[key] = *revocation
+		}
+		revocationStore.lock.Unlock()
+		return nil
+	}
+
+	revocationBytes, err := json.Marshal(revocation)
+	if err != nil {
+		return &constants.ERROR_STORING_REVOCATION
+	}
+
+	pipeline := revocationStore.redisClient.TxPipeline()
+	for _, key := range keys {
+		pipeline.Set(context.Background(), key, revocationBytes, time.Until(revocation.ExpiresAt))
+	}
+	if _, err := pipeline.Exec(context.Background()); err != nil {
+		return &constants.ERROR_STORING_REVOCATION
+	}
+	return nil
+}
+
+// Looks up the token, its user and its sign-in in a single round trip
+func (revocationStore *RevocationStore) IsRevoked(token *interfaces.Token) (bool, *string) {
+	email := strings.ToLower(token.Email)
+	keys := []string{
+		revocationKey(model.REVOCATION_TOKEN, token.TokenID),
+		revocationKey(model.REVOCATION_USER, email),
+	}
+	if token.AuthTime != 0 {
+		keys = append(keys, revocationKey(model.REVOCATION_AUTH_TIME, authTimeSubject(email, token.AuthTime)))
+	}
+	for _, group := range token.Groups {
+		keys = append(keys, revocationKey(model.REVOCATION_GROUP, groupSubject(token.Sub, group)))
+	}
+
+	revocations, err := revocationStore.get(keys)
+	if err != nil {
+		return false, err
+	}
+
+	// Tokens minted after a user or group revocation were resolved again and are fine
+	for _, revocation := range revocations {
+		if (revocation.Type == model.REVOCATION_USER || revocation.Type == model.REVOCATION_GROUP) && int64(token.Iat) > revocation.RevokedAt.Unix() {
+			continue
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (revocationStore *RevocationStore) get(keys []string) ([]model.Revocation, *string) {
+	var revocations []model.Revocation
+
+	if revocationStore.redisClient == nil {
+		revocationStore.lock.RLock()
+		defer revocationStore.lock.RUnlock()
+		for _, key := range keys {
+			if revocation, found := revocationStore.local[key]; found && time.Now().Before(revocation.ExpiresAt) {
+				revocations = append(revocations, revocation)
+			}
+		}
+		return revocations, nil
+	}
+
+	values, err := revocationStore.redisClient.MGet(context.Background(), keys...).Result()
+	if err != nil {
+		return nil, &constants.ERROR_CHECKING_REVOCATION
+	}
+	for _, value := range values {
+		stringValue, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var revocation model.Revocation
+		if err := json.Unmarshal([]byte(stringValue), &revocation); err == nil {
+			revocations = append(revocations, revocation)
+		}
+	}
+	return revocations, nil
+}
+
+// Every revocation that is still in force, newest first
+func (revocationStore *RevocationStore) List() ([]model.Revocation, *string) {
+	var keys []string
+
+	if revocationStore.redisClient == nil {
+		revocationStore.lock.Lock()
+		for key, revocation := range revocationStore.local {
+			if time.Now().After(revocation.ExpiresAt) {
+				delete(revocationStore.local, key)
+				continue
+			}
+			keys = append(keys, key)
+		}
+		revocationStore.lock.Unlock()
+	} else {
+		iterator := revocationStore.redisClient.Scan(context.Background(), 0, constants.REVOCATION_KEY_PREFIX+"*", 100).Iterator()
+		for iterator.Next(context.Background()) {
+			keys = append(keys, iterator.Val())
+		}
+		if iterator.Err() != nil {
+			return nil, &constants.ERROR_CHECKING_REVOCATION
+		}
+	}
+
+	revocations := []model.Revocation{}
+	if len(keys) == 0 {
+		return revocations, nil
+	}
+
+	allRevocations, err := revocationStore.get(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	// Alias emails are stored under separate keys but are the same revocation
+	seen := map[string]bool{}
+	for _, revocation := range allRevocations {
+		id := fmt.Sprintf("%s|%s|%s|%d|%s|%s|%d", revocation.Type, revocation.TokenID, revocation.Email, revocation.AuthTime, revocation.Student, revocation.Group, revocation.RevokedAt.UnixNano())
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		revocations = append(revocations, revocation)
+	}
+
+	sort.Slice(revocations, func(i, j int) bool {
+		return revocations[i].RevokedAt.After(revocations[j].RevokedAt)
+	})
+	return revocations, nil
+}
+
+// Revoking a raw token only needs its id and expiry, so the signature is not checked
+func NewTokenRevocation(rawToken string) (*model.Revocation, *string) {
+	unverifiedJWT, err := jwt.ParseInsecure([]byte(rawToken))
+	if err != nil {
+		return nil, &constants.ERROR_INVALID_TOKEN
+	}
+
+	return &model.Revocation{
+		Type:      model.REVOCATION_TOKEN,
+		TokenID:   GetTokenID(unverifiedJWT.JwtID(), rawToken),
+		ExpiresAt: unverifiedJWT.Expiration(),
+	}, nil
+}
+
+// Cuts off every token and refresh token of the students
+func RevokeStudents(mongikClient *models.Mongik, revocationStore *RevocationStore, studentIds []primitive.ObjectID, revokedBy string, reason string) *string {
+	students, err := db.Aggregate[model.StudentPopulated](mongikClient, constants.DB, constants.COLLECTION_STUDENT, []bson.M{{
+		"$match": bson.M{"_id": bson.M{"$in": studentIds}},
+	}}, true)
+	if err != nil {
+		return &constants.ERROR_FAILED_FETCH_FROM_DB
+	}
+
+	for _, student := range students {
+		if revokeErr := revocationStore.Revoke(&model.Revocation{
+			Type:      model.REVOCATION_USER,
+			Email:     student.InstituteEmail,
+			Reason:    reason,
+			RevokedBy: revokedBy,
+		}); revokeErr != nil {
+			return revokeErr
+		}
+	}
+
+	return revokeRefreshSessions(mongikClient, studentIds)
+}
+
+// For students that lost groups. Only the session tokens with those groups baked in are cut off, every other
+// token resolves the groups when it is verified and refreshing mints a token with the remaining ones
+func RevokeGroups(revocationStore *RevocationStore, studentIds []primitive.ObjectID, groupIds []primitive.ObjectID, revokedBy string, reason string) *string {
+	for _, studentId := range studentIds {
+		for _, groupId := range groupIds {
+			if err := revocationStore.Revoke(&model.Revocation{
+				Type:      model.REVOCATION_GROUP,
+				Student:   studentId.Hex(),
+				Group:     groupId.Hex(),
+				Reason:    reason,
+				RevokedBy: revokedBy,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Refresh tokens of the student can no longer be exchanged for session tokens
+func RevokeRefreshSessionsByEmail(mongikClient *models.Mongik, email string) *string {
+	students, err := db.Aggregate[model.StudentPopulated](mongikClient, constants.DB, constants.COLLECTION_STUDENT, []bson.M{{
+		"$match": bson.M{"email": bson.M{"$in": getAliasEmailList(strings.ToLower(email))}},
+	}}, true)
+	if err != nil {
+		return &constants.ERROR_FAILED_FETCH_FROM_DB
+	}
+
+	var studentIds []primitive.ObjectID
+	for _, student := range students {
+		studentIds = append(studentIds, student.Id)
+	}
+	return revokeRefreshSessions(mongikClient, studentIds)
+}
+
+func revokeRefreshSessions(mongikClient *models.Mongik, studentIds []primitive.ObjectID) *string {
+	if len(studentIds) == 0 {
+		return nil
+	}
+
+	if _, err := db.UpdateMany[model.RefreshSession](mongikClient, constants.DB, constants.COLLECTION_SESSION, bson.M{
+		"student": bson.M{"$in": studentIds},
+		"revoked": false,
+	}, bson.M{
+		"$set": bson.M{"revoked": true},
+	}); err != nil {
+		return &constants.ERROR_STORING_REVOCATION
+	}
+	return nil
+}
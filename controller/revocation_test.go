@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRevocationStoreIsRevoked(t *testing.T) {
+	now := time.Now()
+	before := int(now.Add(-time.Minute).Unix())
+	after := int(now.Add(time.Minute).Unix())
+	student := primitive.NewObjectID().Hex()
+	group := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name       string
+		revocation model.Revocation
+		token      interfaces.Token
+		revoked    bool
+	}{
+		{"token", model.Revocation{Type: model.REVOCATION_TOKEN, TokenID: "jti"}, interfaces.Token{TokenID: "jti", Iat: before}, true},
+		{"another token", model.Revocation{Type: model.REVOCATION_TOKEN, TokenID: "jti"}, interfaces.Token{TokenID: "other", Iat: before}, false},
+		{"token minted later is still revoked by id", model.Revocation{Type: model.REVOCATION_TOKEN, TokenID: "jti"}, interfaces.Token{TokenID: "jti", Iat: after}, true},
+		{"user", model.Revocation{Type: model.REVOCATION_USER, Email: "someone.cse20@iitbhu.ac.in"}, interfaces.Token{Email: "someone.cse20@iitbhu.ac.in", Iat: before}, true},
+		{"user by alias email", model.Revocation{Type: model.REVOCATION_USER, Email: "someone.cse20@iitbhu.ac.in"}, interfaces.Token{Email: "someone.cse20@itbhu.ac.in", Iat: before}, true},
+		{"user by upper case email", model.Revocation{Type: model.REVOCATION_USER, Email: "Someone.CSE20@itbhu.ac.in"}, interfaces.Token{Email: "SOMEONE.cse20@iitbhu.ac.in", Iat: before}, true},
+		{"another user", model.Revocation{Type: model.REVOCATION_USER, Email: "someone.cse20@iitbhu.ac.in"}, interfaces.Token{Email: "other.cse20@iitbhu.ac.in", Iat: before}, false},
+		{"user token minted after the revocation", model.Revocation{Type: model.REVOCATION_USER, Email: "someone.cse20@iitbhu.ac.in"}, interfaces.Token{Email: "someone.cse20@iitbhu.ac.in", Iat: after}, false},
+		{"sign-in", model.Revocation{Type: model.REVOCATION_AUTH_TIME, Email: "someone.cse20@iitbhu.ac.in", AuthTime: 1700000000}, interfaces.Token{Email: "someone.cse20@itbhu.ac.in", AuthTime: 1700000000, Iat: after}, true},
+		{"another sign-in", model.Revocation{Type: model.REVOCATION_AUTH_TIME, Email: "someone.cse20@iitbhu.ac.in", AuthTime: 1700000000}, interfaces.Token{Email: "someone.cse20@iitbhu.ac.in", AuthTime: 1700000001, Iat: before}, false},
+		{"group", model.Revocation{Type: model.REVOCATION_GROUP, Student: student, Group: group}, interfaces.Token{Sub: student, Groups: []string{"other", group}, Iat: before}, true},
+		{"group of another student", model.Revocation{Type: model.REVOCATION_GROUP, Student: student, Group: group}, interfaces.Token{Sub: primitive.NewObjectID().Hex(), Groups: []string{group}, Iat: before}, false},
+		{"token without the group", model.Revocation{Type: model.REVOCATION_GROUP, Student: student, Group: group}, interfaces.Token{Sub: student, Groups: []string{"other"}, Iat: before}, false},
+		{"group token minted after the revocation", model.Revocation{Type: model.REVOCATION_GROUP, Student: student, Group: group}, interfaces.Token{Sub: student, Groups: []string{group}, Iat: after}, false},
+		{"expired revocation", model.Revocation{Type: model.REVOCATION_TOKEN, TokenID: "jti", ExpiresAt: now.Add(-time.Second)}, interfaces.Token{TokenID: "jti", Iat: before}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			revocationStore := NewRevocationStore(nil)
+			revocation := test.revocation
+			revocation.RevokedAt = now
+			if err := revocationStore.Revoke(&revocation); err != nil {
+				t.Fatalf("Revoke() error = %s", *err)
+			}
+
+			revoked, err := revocationStore.IsRevoked(&test.token)
+			if err != nil {
+				t.Fatalf("IsRevoked() error = %s", *err)
+			}
+			if revoked != test.revoked {
+				t.Errorf("IsRevoked() = %v, want %v", revoked, test.revoked)
+			}
+		})
+	}
+}
+
+func TestRevocationStoreRevokeInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		revocation model.Revocation
+	}{
+		{"unknown type", model.Revocation{Type: "everything"}},
+		{"group without a student", model.Revocation{Type: model.REVOCATION_GROUP, Group: primitive.NewObjectID().Hex()}},
+		{"group without a group", model.Revocation{Type: model.REVOCATION_GROUP, Student: primitive.NewObjectID().Hex()}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := NewRevocationStore(nil).Revoke(&test.revocation); err == nil || *err != constants.ERROR_INVALID_REVOCATION {
+				t.Errorf("Revoke() error = %v, want %s", err, constants.ERROR_INVALID_REVOCATION)
+			}
+		})
+	}
+}
+
+func TestRevocationStoreRetention(t *testing.T) {
+	tests := []struct {
+		name       string
+		revocation model.Revocation
+		retention  time.Duration
+	}{
+		{"token", model.Revocation{Type: model.REVOCATION_TOKEN, TokenID: "jti"}, constants.REVOCATION_RETENTION},
+		{"user", model.Revocation{Type: model.REVOCATION_USER, Email: "someone@iitbhu.ac.in"}, constants.REVOCATION_RETENTION},
+		{"sign-in", model.Revocation{Type: model.REVOCATION_AUTH_TIME, Email: "someone@iitbhu.ac.in", AuthTime: 1700000000}, constants.REVOCATION_AUTH_TIME_RETENTION},
+		{"group", model.Revocation{Type: model.REVOCATION_GROUP, Student: "student", Group: "group"}, constants.SESSION_ACCESS_TOKEN_TTL},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			revocation := test.revocation
+			if err := NewRevocationStore(nil).Revoke(&revocation); err != nil {
+				t.Fatalf("Revoke() error = %s", *err)
+			}
+			if got := revocation.ExpiresAt.Sub(revocation.RevokedAt); got != test.retention {
+				t.Errorf("retention = %s, want %s", got, test.retention)
+			}
+		})
+	}
+}
+
+func TestRevocationStoreList(t *testing.T) {
+	revocationStore := NewRevocationStore(nil)
+	revocations := []model.Revocation{
+		{Type: model.REVOCATION_USER, Email: "someone.cse20@iitbhu.ac.in", RevokedAt: time.Now().Add(-time.Minute)},
+		{Type: model.REVOCATION_TOKEN, TokenID: "jti"},
+		{Type: model.REVOCATION_TOKEN, TokenID: "expired", ExpiresAt: time.Now().Add(-time.Second)},
+	}
+	for idx := range revocations {
+		if err := revocationStore.Revoke(&revocations[idx]); err != nil {
+			t.Fatalf("Revoke() error = %s", *err)
+		}
+	}
+
+	// The user revocation is stored under every alias email but listed once
+	list, err := revocationStore.List()
+	if err != nil {
+		t.Fatalf("List() error = %s", *err)
+	}
+	if len(list) != 2 || list[0].TokenID != "jti" || list[1].Type != model.REVOCATION_USER {
+		t.Errorf("List() = %+v, want the token and then the user revocation", list)
+	}
+}
+
+func TestRevokeGroups(t *testing.T) {
+	revocationStore := NewRevocationStore(nil)
+	students := []primitive.ObjectID{primitive.NewObjectID(), primitive.NewObjectID()}
+	groups := []primitive.ObjectID{primitive.NewObjectID(), primitive.NewObjectID()}
+	if err := RevokeGroups(revocationStore, students, groups[:1], "admin", "Removed from groups"); err != nil {
+		t.Fatalf("RevokeGroups() error = %s", *err)
+	}
+
+	iat := int(time.Now().Add(-time.Minute).Unix())
+	for _, student := range students {
+		revoked, _ := revocationStore.IsRevoked(&interfaces.Token{Sub: student.Hex(), Groups: []string{groups[0].Hex()}, Iat: iat})
+		if !revoked {
+			t.Errorf("token of %s with the removed group is not revoked", student.Hex())
+		}
+		revoked, _ = revocationStore.IsRevoked(&interfaces.Token{Sub: student.Hex(), Groups: []string{groups[1].Hex()}, Iat: iat})
+		if revoked {
+			t.Errorf("token of %s with another group is revoked", student.Hex())
+		}
+	}
+}
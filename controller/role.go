@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
+	db "github.com/FrosTiK-SD/mongik/db"
+	models "github.com/FrosTiK-SD/mongik/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Reads a JSON object mapping each role to the roles it implies
+func LoadRoleImplications(configPath string) (map[string][]string, error) {
+	configBytes, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var implications map[string][]string
+	if err := json.Unmarshal(configBytes, &implications); err != nil {
+		return nil, err
+	}
+	return implications, nil
+}
+
+// Merges the built-in implications with ROLE_GRAPH_CONFIG and the roles collection, later sources add to earlier ones
+func LoadRoleGraph(mongikClient *models.Mongik) *util.RoleGraph {
+	implications := map[string][]string{}
+	merge := func(extra map[string][]string) {
+		for role, impliedRoles := range extra {
+			implications[role] = append(implications[role], impliedRoles...)
+		}
+	}
+	merge(constants.DEFAULT_ROLE_IMPLICATIONS)
+
+	if configPath := os.Getenv(constants.ROLE_GRAPH_CONFIG); configPath != "" {
+		configImplications, err := LoadRoleImplications(configPath)
+		if err != nil {
+			fmt.Println("Error loading role graph config:", err)
+		} else {
+			merge(configImplications)
+		}
+	}
+
+	if mongikClient != nil {
+		roles, err := db.Aggregate[model.Role](mongikClient, constants.DB, constants.COLLECTION_ROLE, []bson.M{}, true)
+		if err != nil {
+			fmt.Println("Error loading roles:", err)
+		}
+		for _, role := range roles {
+			merge(map[string][]string{role.Name: role.Implies})
+		}
+	}
+
+	return util.NewRoleGraph(implications)
+}
+
+var startRoleGraphRefreshOnce sync.Once
+
+// Loads the role graph now and keeps reloading it so edits to the roles collection are picked up
+func StartRoleGraphRefresh(mongikClient *models.Mongik) {
+	startRoleGraphRefreshOnce.Do(func() {
+		util.SetRoleGraph(LoadRoleGraph(mongikClient))
+
+		go func() {
+			for range time.Tick(constants.ROLE_GRAPH_REFRESH_INTERVAL) {
+				util.SetRoleGraph(LoadRoleGraph(mongikClient))
+			}
+		}()
+	})
+}
+
+// Roles missing from the catalog, such as a misspelt GROUP_REDA
+func GetUnknownRoles(roles []string) []string {
+	roleGraph := util.GetRoleGraph()
+	var unknownRoles []string
+	for _, role := range roles {
+		if !roleGraph.IsKnown(role) && !util.ArrayContains(unknownRoles, role) {
+			unknownRoles = append(unknownRoles, role)
+		}
+	}
+	return unknownRoles
+}
+
+func ValidateRoles(roles []string) *string {
+	if len(GetUnknownRoles(roles)) != 0 {
+		return &constants.ERROR_UNKNOWN_ROLE
+	}
+	return nil
+}
+
+// Every known role with its description, implications and the routes of the route policy that check it
+func GetRoleCatalog(mongikClient *models.Mongik, noCache bool) ([]interfaces.RoleCatalogEntry, error) {
+	roles, err := db.Aggregate[model.Role](mongikClient, constants.DB, constants.COLLECTION_ROLE, []bson.M{}, noCache)
+	if err != nil {
+		return nil, err
+	}
+	descriptions := map[string]string{}
+	for role, description := range constants.ROLE_DESCRIPTIONS {
+		descriptions[role] = description
+	}
+	for _, role := range roles {
+		if role.Description != "" {
+			descriptions[role.Name] = role.Description
+		}
+	}
+
+	routes := map[string][]string{}
+	for _, rule := range GetRoutePolicy().Rules {
+		if rule.Roles == nil {
+			continue
+		}
+		for _, role := range rule.Roles.RoleNames() {
+			route := rule.Method + " " + rule.Path
+			if !util.ArrayContains(routes[role], route) {
+				routes[role] = append(routes[role], route)
+			}
+		}
+	}
+
+	roleGraph := util.GetRoleGraph()
+	implications := roleGraph.Implications()
+	catalog := []interfaces.RoleCatalogEntry{}
+	for _, role := range roleGraph.Known() {
+		catalog = append(catalog, interfaces.RoleCatalogEntry{
+			Name:        role,
+			Description: descriptions[role],
+			Implies:     implications[role],
+			Routes:      routes[role],
+			BuiltIn:     util.ArrayContains(constants.ALL_ROLES, role),
+		})
+	}
+
+	return catalog, nil
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
+	"github.com/FrosTiK-SD/models/company"
+	db "github.com/FrosTiK-SD/mongik/db"
+	mongikModels "github.com/FrosTiK-SD/mongik/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// The scope is kept on the group document next to the fields of company.Group
+func GetGroupScopes(mongikClient *mongikModels.Mongik, groupIds []primitive.ObjectID, noCache bool) ([]model.GroupScope, error) {
+	return db.Aggregate[model.GroupScope](mongikClient, constants.DB, constants.COLLECTION_GROUP, []bson.M{
+		{"$match": bson.M{"_id": bson.M{"$in": groupIds}}},
+		{"$project": bson.M{"name": 1, "roles": 1, "scope": 1}},
+	}, noCache)
+}
+
+// Limits a listing to the students the actor holds the expression over. Nil when the institute wide groups
+// meet it, otherwise the scopes of every group that meets it together with the institute wide ones
+func GetScopeFilter(mongikClient *mongikModels.Mongik, actor *model.StudentPopulated, expression util.RoleExpression, noCache bool) (bson.M, error) {
+	groupIds := make([]primitive.ObjectID, 0, len(actor.GroupDetails))
+	for _, group := range actor.GroupDetails {
+		groupIds = append(groupIds, group.ID)
+	}
+
+	groupScopes, err := GetGroupScopes(mongikClient, groupIds, noCache)
+	if err != nil {
+		return nil, err
+	}
+
+	var unscopedRoles []string
+	for _, groupScope := range groupScopes {
+		if groupScope.Scope == nil {
+			unscopedRoles = append(unscopedRoles, groupScope.Roles...)
+		}
+	}
+	if met, _ := expression.Evaluate(util.NewRoleSetFromRoles(unscopedRoles)); met {
+		return nil, nil
+	}
+
+	filters := []bson.M{}
+	for _, groupScope := range groupScopes {
+		if groupScope.Scope == nil {
+			continue
+		}
+		roles := append(append([]string{}, unscopedRoles...), groupScope.Roles...)
+		if met, _ := expression.Evaluate(util.NewRoleSetFromRoles(roles)); met {
+			filters = append(filters, groupScope.Scope.Filter(&actor.Student))
+		}
+	}
+	if len(filters) == 0 {
+		return bson.M{"_id": bson.M{"$in": []primitive.ObjectID{}}}, nil
+	}
+	return bson.M{"$or": filters}, nil
+}
+
+// Adds the scope filter of the actor to a query, a nil scope filter leaves it as it is
+func withScopeFilter(filter bson.M, scopeFilter bson.M) bson.M {
+	if scopeFilter == nil {
+		return filter
+	}
+	return bson.M{"$and": []bson.M{filter, scopeFilter}}
+}
+
+// A nil scope makes the groups institute wide again
+func SetGroupScope(mongikClient *mongikModels.Mongik, groupIds []primitive.ObjectID, scope *model.Scope) (*mongo.UpdateResult, error) {
+	update := bson.M{"$unset": bson.M{"scope": ""}}
+	if scope != nil {
+		update = bson.M{"$set": bson.M{"scope": scope}}
+	}
+
+	return db.UpdateMany[company.Group](mongikClient, constants.DB, constants.COLLECTION_GROUP, bson.M{
+		"_id": bson.M{"$in": groupIds},
+	}, update)
+}
+
+// Roles the actor holds over the target, only counting groups whose scope matches the target
+func GetScopedRoleSet(mongikClient *mongikModels.Mongik, actor *model.StudentPopulated, target *model.StudentPopulated, noCache bool) (util.RoleSet, error) {
+	groupIds := make([]primitive.ObjectID, 0, len(actor.GroupDetails))
+	for _, group := range actor.GroupDetails {
+		groupIds = append(groupIds, group.ID)
+	}
+
+	groupScopes, err := GetGroupScopes(mongikClient, groupIds, noCache)
+	if err != nil {
+		return nil, err
+	}
+
+	var roles []string
+	for _, groupScope := range groupScopes {
+		if groupScope.Scope.Matches(&actor.Student, &target.Student) {
+			roles = append(roles, groupScope.Roles...)
+		}
+	}
+
+	return util.NewRoleSetFromRoles(roles), nil
+}
@@ -0,0 +1,285 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/models/company"
+	db "github.com/FrosTiK-SD/mongik/db"
+	models "github.com/FrosTiK-SD/mongik/models"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Mints the first-party access tokens of this service
+type SessionIssuer struct {
+	Issuer    string
+	Audience  string
+	Algorithm jwa.SignatureAlgorithm
+	signKey   jwk.Key
+	publicSet jwk.Set
+}
+
+// Loads the PEM signing key from SESSION_SIGNING_KEY. Session tokens are disabled without one, which is not an error
+func NewSessionIssuer() (*SessionIssuer, error) {
+	pemKey := os.Getenv(constants.SESSION_SIGNING_KEY)
+	if pemKey == "" {
+		return nil, nil
+	}
+
+	signKey, err := jwk.ParseKey([]byte(pemKey), jwk.WithPEM(true))
+	if err != nil {
+		return nil, err
+	}
+
+	var algorithm jwa.SignatureAlgorithm
+	switch signKey.KeyType() {
+	case jwa.RSA:
+		algorithm = jwa.RS256
+	case jwa.EC:
+		algorithm = jwa.ES256
+	case jwa.OKP:
+		algorithm = jwa.EdDSA
+	default:
+		return nil, errors.New("unsupported session signing key type")
+	}
+
+	if err := jwk.AssignKeyID(signKey); err != nil {
+		return nil, err
+	}
+	signKey.Set(jwk.AlgorithmKey, algorithm)
+
+	publicKey, err := jwk.PublicKeyOf(signKey)
+	if err != nil {
+		return nil, err
+	}
+	publicKey.Set(jwk.KeyIDKey, signKey.KeyID())
+	publicKey.Set(jwk.AlgorithmKey, algorithm)
+	publicKey.Set(jwk.KeyUsageKey, jwk.ForSignature)
+
+	publicSet := jwk.NewSet()
+	publicSet.AddKey(publicKey)
+
+	issuer := os.Getenv(constants.SESSION_ISSUER)
+	if issuer == "" {
+		issuer = constants.DEFAULT_SESSION_ISSUER
+	}
+	audience := os.Getenv(constants.SESSION_AUDIENCE)
+	if audience == "" {
+		audience = issuer
+	}
+
+	return &SessionIssuer{
+		Issuer:    issuer,
+		Audience:  audience,
+		Algorithm: algorithm,
+		signKey:   signKey,
+		publicSet: publicSet,
+	}, nil
+}
+
+// Public keys that downstream services verify session tokens with
+func (sessionIssuer *SessionIssuer) PublicKeySet() jwk.Set {
+	return sessionIssuer.publicSet
+}
+
+// Lets the key manager verify the tokens minted here
+func (sessionIssuer *SessionIssuer) GetIssuerSource() IssuerSource {
+	return IssuerSource{
+		Issuer: interfaces.TrustedIssuer{
+			Issuer:     sessionIssuer.Issuer,
+			Audience:   sessionIssuer.Audience,
+			EmailClaim: constants.DEFAULT_EMAIL_CLAIM,
+			FirstParty: true,
+		},
+		Source: NewStaticJWKSource(sessionIssuer.publicSet),
+	}
+}
+
+func (sessionIssuer *SessionIssuer) IsSessionToken(token *interfaces.Token) bool {
+	return sessionIssuer != nil && token.Iss == sessionIssuer.Issuer
+}
+
+// Signs a short-lived access token with the student id, resolved roles and active groups baked in.
+// The auth_time of the original sign-in is carried over so that it can still be revoked
+func (sessionIssuer *SessionIssuer) MintAccessToken(student *model.StudentPopulated, authTime int) (string, time.Time, *string) {
+	now := time.Now()
+	exp := now.Add(constants.SESSION_ACCESS_TOKEN_TTL)
+
+	groupIds := make([]string, 0, len(student.GroupDetails))
+	for _, group := range student.GroupDetails {
+		groupIds = append(groupIds, group.ID.Hex())
+	}
+
+	accessToken, err := jwt.NewBuilder().
+		Issuer(sessionIssuer.Issuer).
+		Audience([]string{sessionIssuer.Audience}).
+		Subject(student.Id.Hex()).
+		IssuedAt(now).
+		Expiration(exp).
+		JwtID(primitive.NewObjectID().Hex()).
+		Claim(constants.DEFAULT_EMAIL_CLAIM, student.InstituteEmail).
+		Claim("roles", student.RoleSet().List()).
+		Claim("groups", groupIds).
+		Claim("token_use", constants.TOKEN_USE_ACCESS).
+		Claim("auth_time", authTime).
+		Build()
+	if err != nil {
+		return "", exp, &constants.ERROR_SIGNING_SESSION_TOKEN
+	}
+
+	signedToken, err := jwt.Sign(accessToken, jwt.WithKey(sessionIssuer.Algorithm, sessionIssuer.signKey))
+	if err != nil {
+		return "", exp, &constants.ERROR_SIGNING_SESSION_TOKEN
+	}
+
+	return string(signedToken), exp, nil
+}
+
+// Session tokens carry the student id, roles and groups resolved when they were minted, so only the student
+// document is read, without the group lookup and memberships. Role changes apply once the token is refreshed
+func GetStudentBySessionToken(mongikClient *models.Mongik, token *interfaces.Token, noCache bool) (*model.StudentPopulated, *string) {
+	studentId, err := primitive.ObjectIDFromHex(token.Sub)
+	if err != nil {
+		return nil, &constants.ERROR_INVALID_TOKEN
+	}
+
+	// Every session token is minted with the roles of its student, so one without them was not issued here
+	if len(token.Roles) == 0 {
+		return nil, &constants.ERROR_INVALID_TOKEN
+	}
+
+	student, err := db.AggregateOne[model.StudentPopulated](mongikClient, constants.DB, constants.COLLECTION_STUDENT, []bson.M{
+		{"$match": bson.M{"_id": studentId}},
+		{"$project": bson.M{"groups": 0}},
+	}, noCache)
+	if err != nil || student.Id.IsZero() {
+		return nil, &constants.ERROR_NOT_A_STUDENT
+	}
+
+	// Only the ids are known, which is all that scope checks need
+	for _, groupIdHex := range token.Groups {
+		if groupId, err := primitive.ObjectIDFromHex(groupIdHex); err == nil {
+			student.GroupDetails = append(student.GroupDetails, company.Group{ID: groupId})
+		}
+	}
+	student.SetResolvedRoles(token.Roles)
+
+	if !student.RoleSet().Has(constants.ROLE_STUDENT) {
+		return nil, &constants.ERROR_NOT_A_STUDENT
+	}
+
+	return &student, nil
+}
+
+func hashRefreshToken(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// Refresh tokens look like <session id>.<secret>, only the hash of the secret is stored
+func createRefreshSession(mongikClient *models.Mongik, studentId primitive.ObjectID, authTime int, family primitive.ObjectID) (string, *string) {
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", &constants.ERROR_CREATING_SESSION
+	}
+	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	now := time.Now()
+	session := model.RefreshSession{
+		Id:        primitive.NewObjectID(),
+		Family:    family,
+		Student:   studentId,
+		TokenHash: hashRefreshToken(secret),
+		AuthTime:  authTime,
+		ExpiresAt: primitive.NewDateTimeFromTime(now.Add(constants.SESSION_REFRESH_TOKEN_TTL)),
+		CreatedAt: primitive.NewDateTimeFromTime(now),
+	}
+	if session.Family.IsZero() {
+		session.Family = session.Id
+	}
+
+	if _, err := db.InsertOne(mongikClient, constants.DB, constants.COLLECTION_SESSION, session); err != nil {
+		return "", &constants.ERROR_CREATING_SESSION
+	}
+
+	return session.Id.Hex() + "." + secret, nil
+}
+
+func CreateRefreshToken(mongikClient *models.Mongik, studentId primitive.ObjectID, authTime int) (string, *string) {
+	return createRefreshSession(mongikClient, studentId, authTime, primitive.NilObjectID)
+}
+
+// Swaps a refresh token for a new one and returns the session it replaced.
+// Presenting an already rotated token revokes the whole family
+func RotateRefreshToken(mongikClient *models.Mongik, refreshToken string) (string, *model.RefreshSession, *string) {
+	sessionIdHex, secret, found := strings.Cut(refreshToken, ".")
+	if !found {
+		return "", nil, &constants.ERROR_INVALID_REFRESH_TOKEN
+	}
+	sessionId, err := primitive.ObjectIDFromHex(sessionIdHex)
+	if err != nil {
+		return "", nil, &constants.ERROR_INVALID_REFRESH_TOKEN
+	}
+
+	sessionCollection := mongikClient.MongoClient.Database(constants.DB).Collection(constants.COLLECTION_SESSION)
+
+	var session model.RefreshSession
+	if err := sessionCollection.FindOne(context.Background(), bson.M{"_id": sessionId}).Decode(&session); err != nil {
+		return "", nil, &constants.ERROR_INVALID_REFRESH_TOKEN
+	}
+
+	if subtle.ConstantTimeCompare([]byte(session.TokenHash), []byte(hashRefreshToken(secret))) != 1 {
+		return "", nil, &constants.ERROR_INVALID_REFRESH_TOKEN
+	}
+	if session.Revoked || session.ExpiresAt.Time().Before(time.Now()) {
+		return "", nil, &constants.ERROR_INVALID_REFRESH_TOKEN
+	}
+
+	// Only one caller may rotate a token, everyone else is replaying it
+	rotateResult := sessionCollection.FindOneAndUpdate(context.Background(), bson.M{
+		"_id":       sessionId,
+		"rotatedAt": nil,
+	}, bson.M{
+		"$set": bson.M{"rotatedAt": primitive.NewDateTimeFromTime(time.Now())},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+
+	if rotateErr := rotateResult.Err(); rotateErr != nil {
+		if errors.Is(rotateErr, mongo.ErrNoDocuments) {
+			RevokeRefreshSessionFamily(mongikClient, session.Family)
+			return "", nil, &constants.ERROR_REFRESH_TOKEN_REUSED
+		}
+		return "", nil, &constants.ERROR_INVALID_REFRESH_TOKEN
+	}
+
+	newRefreshToken, createErr := createRefreshSession(mongikClient, session.Student, session.AuthTime, session.Family)
+	if createErr != nil {
+		return "", nil, createErr
+	}
+
+	return newRefreshToken, &session, nil
+}
+
+func RevokeRefreshSessionFamily(mongikClient *models.Mongik, family primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return db.UpdateMany[model.RefreshSession](mongikClient, constants.DB, constants.COLLECTION_SESSION, bson.M{
+		"family": family,
+	}, bson.M{
+		"$set": bson.M{"revoked": true},
+	})
+}
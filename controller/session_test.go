@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/mongik"
+	mongikConstants "github.com/FrosTiK-SD/mongik/constants"
+	models "github.com/FrosTiK-SD/mongik/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Rotation relies on atomic updates of Mongo, so those tests run against the database in TEST_ATLAS_URI
+const testConnectionString = "TEST_" + constants.CONNECTION_STRING
+
+func newTestMongik(t *testing.T) *models.Mongik {
+	t.Helper()
+	connectionString := os.Getenv(testConnectionString)
+	if connectionString == "" {
+		t.Skip(testConnectionString + " is not set")
+	}
+	return mongik.NewClient(connectionString, &models.Config{
+		Client: mongikConstants.BIGCACHE,
+		TTL:    time.Minute,
+	})
+}
+
+// Creates a refresh token for a fresh student and drops its sessions once the test is over
+func newTestRefreshToken(t *testing.T, mongikClient *models.Mongik) (string, primitive.ObjectID) {
+	t.Helper()
+	studentId := primitive.NewObjectID()
+	t.Cleanup(func() {
+		mongikClient.MongoClient.Database(constants.DB).Collection(constants.COLLECTION_SESSION).DeleteMany(context.Background(), bson.M{
+			"student": studentId,
+		})
+	})
+
+	refreshToken, err := CreateRefreshToken(mongikClient, studentId, 1700000000)
+	if err != nil {
+		t.Fatalf("CreateRefreshToken() error = %s", *err)
+	}
+	return refreshToken, studentId
+}
+
+func updateTestSession(t *testing.T, mongikClient *models.Mongik, refreshToken string, update bson.M) {
+	t.Helper()
+	sessionIdHex, _, _ := strings.Cut(refreshToken, ".")
+	sessionId, _ := primitive.ObjectIDFromHex(sessionIdHex)
+	if _, err := mongikClient.MongoClient.Database(constants.DB).Collection(constants.COLLECTION_SESSION).UpdateOne(context.Background(), bson.M{
+		"_id": sessionId,
+	}, bson.M{"$set": update}); err != nil {
+		t.Fatalf("UpdateOne() error = %v", err)
+	}
+}
+
+func expectRotateError(t *testing.T, mongikClient *models.Mongik, refreshToken string, want string) {
+	t.Helper()
+	if _, _, err := RotateRefreshToken(mongikClient, refreshToken); err == nil || *err != want {
+		t.Errorf("RotateRefreshToken() error = %v, want %s", err, want)
+	}
+}
+
+func TestRotateRefreshTokenMalformed(t *testing.T) {
+	tests := []struct {
+		name         string
+		refreshToken string
+	}{
+		{"empty", ""},
+		{"no secret", primitive.NewObjectID().Hex()},
+		{"invalid session id", "session.secret"},
+	}
+
+	// Malformed tokens are refused before the database is asked
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expectRotateError(t, nil, test.refreshToken, constants.ERROR_INVALID_REFRESH_TOKEN)
+		})
+	}
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	mongikClient := newTestMongik(t)
+	refreshToken, studentId := newTestRefreshToken(t, mongikClient)
+
+	newRefreshToken, session, err := RotateRefreshToken(mongikClient, refreshToken)
+	if err != nil {
+		t.Fatalf("RotateRefreshToken() error = %s", *err)
+	}
+	if newRefreshToken == "" || newRefreshToken == refreshToken {
+		t.Errorf("RotateRefreshToken() = %q, want a new refresh token", newRefreshToken)
+	}
+	if session.Student != studentId || session.AuthTime != 1700000000 {
+		t.Errorf("RotateRefreshToken() session = %+v, want the student and auth_time of the old one", session)
+	}
+
+	// The new token is in the same family and can be rotated in turn
+	if _, _, err := RotateRefreshToken(mongikClient, newRefreshToken); err != nil {
+		t.Errorf("RotateRefreshToken() of the new token error = %s", *err)
+	}
+}
+
+func TestRotateRefreshTokenRefused(t *testing.T) {
+	mongikClient := newTestMongik(t)
+
+	tests := []struct {
+		name   string
+		update bson.M
+		secret string
+	}{
+		{"wrong secret", nil, "not-the-secret"},
+		{"expired", bson.M{"expiresAt": primitive.NewDateTimeFromTime(time.Now().Add(-time.Minute))}, ""},
+		{"revoked", bson.M{"revoked": true}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			refreshToken, _ := newTestRefreshToken(t, mongikClient)
+			if test.update != nil {
+				updateTestSession(t, mongikClient, refreshToken, test.update)
+			}
+			if test.secret != "" {
+				sessionIdHex, _, _ := strings.Cut(refreshToken, ".")
+				refreshToken = sessionIdHex + "." + test.secret
+			}
+			expectRotateError(t, mongikClient, refreshToken, constants.ERROR_INVALID_REFRESH_TOKEN)
+		})
+	}
+
+	t.Run("unknown session", func(t *testing.T) {
+		expectRotateError(t, mongikClient, primitive.NewObjectID().Hex()+".secret", constants.ERROR_INVALID_REFRESH_TOKEN)
+	})
+}
+
+func TestRotateRefreshTokenReplay(t *testing.T) {
+	mongikClient := newTestMongik(t)
+	refreshToken, _ := newTestRefreshToken(t, mongikClient)
+
+	newRefreshToken, _, err := RotateRefreshToken(mongikClient, refreshToken)
+	if err != nil {
+		t.Fatalf("RotateRefreshToken() error = %s", *err)
+	}
+
+	// Replaying the rotated token revokes the family, so the token it was swapped for stops working too
+	expectRotateError(t, mongikClient, refreshToken, constants.ERROR_REFRESH_TOKEN_REUSED)
+	expectRotateError(t, mongikClient, newRefreshToken, constants.ERROR_INVALID_REFRESH_TOKEN)
+	expectRotateError(t, mongikClient, refreshToken, constants.ERROR_INVALID_REFRESH_TOKEN)
+}
+
+func TestRotateRefreshTokenConcurrent(t *testing.T) {
+	mongikClient := newTestMongik(t)
+	refreshToken, _ := newTestRefreshToken(t, mongikClient)
+
+	const callers = 8
+	var wg sync.WaitGroup
+	newRefreshTokens := make([]string, callers)
+	errs := make([]*string, callers)
+	for idx := 0; idx < callers; idx++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			newRefreshTokens[idx], _, errs[idx] = RotateRefreshToken(mongikClient, refreshToken)
+		}(idx)
+	}
+	wg.Wait()
+
+	// Exactly one caller rotates. The others either lose the race or find the family revoked already
+	rotated, reused := 0, 0
+	winner := ""
+	for idx := range errs {
+		switch {
+		case errs[idx] == nil:
+			rotated++
+			winner = newRefreshTokens[idx]
+		case *errs[idx] == constants.ERROR_REFRESH_TOKEN_REUSED:
+			reused++
+		case *errs[idx] != constants.ERROR_INVALID_REFRESH_TOKEN:
+			t.Errorf("RotateRefreshToken() error = %s", *errs[idx])
+		}
+	}
+	if rotated != 1 {
+		t.Fatalf("%d callers rotated the token, want 1", rotated)
+	}
+	if reused == 0 {
+		t.Errorf("no caller was told the token was reused")
+	}
+
+	expectRotateError(t, mongikClient, winner, constants.ERROR_INVALID_REFRESH_TOKEN)
+}
@@ -12,7 +12,6 @@ import (
 
 	"github.com/FrosTiK-SD/auth/constants"
 	"github.com/FrosTiK-SD/auth/model"
-	"github.com/FrosTiK-SD/auth/util"
 	"github.com/FrosTiK-SD/models/misc"
 	studentModel "github.com/FrosTiK-SD/models/student"
 	db "github.com/FrosTiK-SD/mongik/db"
@@ -32,6 +31,9 @@ type StudentSearchFilter struct {
 	Course     string
 	Department string
 	Limit      int
+
+	// From GetScopeFilter, nil for actors without scoped groups
+	ScopeFilter bson.M
 }
 
 func getAliasEmailList(email string) []string {
@@ -61,8 +63,14 @@ func GetUserByEmail(mongikClient *models.Mongik, email *string, role *string, no
 		},
 	}}, noCache)
 
+	// Groups held outside their validFrom and validUntil grant nothing
+	if err := ApplyMemberships(mongikClient, &studentPopulated, noCache); err != nil {
+		return nil, &constants.ERROR_RESOLVING_MEMBERSHIPS
+	}
+	studentPopulated.ResolveRoleSet()
+
 	// Now check if it is actually a student by the ROLES
-	if !util.CheckRoleExists(&studentPopulated.GroupDetails, *role) {
+	if !studentPopulated.RoleSet().Has(*role) {
 		return nil, &constants.ERROR_NOT_A_STUDENT
 	}
 
@@ -146,13 +154,13 @@ func VerifyStudentProfile(mongikClient *models.Mongik, studentId primitive.Objec
 	return &student, nil
 }
 
-func UnverifyStudentProfilesByBatch(mongikClient *models.Mongik, startYear int, endYear int) (int, []error) {
+func UnverifyStudentProfilesByBatch(mongikClient *models.Mongik, startYear int, endYear int, scopeFilter bson.M) (int, []error) {
 	studentCollection := mongikClient.MongoClient.Database(constants.DB).Collection(constants.COLLECTION_STUDENT)
 
-	filter := bson.M{
+	filter := withScopeFilter(bson.M{
 		"batch.startYear": startYear,
 		"batch.endYear":   endYear,
-	}
+	}, scopeFilter)
 
 	cursor, err := studentCollection.Find(context.Background(), filter)
 	if err != nil {
@@ -318,7 +326,7 @@ func BuildStudentSearchMatchFilter(filter StudentSearchFilter) (bson.M, bool) {
 		matchFilter["department"] = strings.ToLower(strings.TrimSpace(filter.Department))
 	}
 
-	return matchFilter, true
+	return withScopeFilter(matchFilter, filter.ScopeFilter), true
 }
 
 func SearchStudents(mongikClient *models.Mongik, filter StudentSearchFilter, noCache bool) (*[]model.StudentPopulated, error) {
@@ -383,9 +391,9 @@ func BuildStudentExportFilter(startYear int, endYear int, status string) bson.M
 	return filter
 }
 
-func GetStudentsForExport(mongikClient *models.Mongik, startYear int, endYear int, status string) (*[]studentModel.Student, error) {
+func GetStudentsForExport(mongikClient *models.Mongik, startYear int, endYear int, status string, scopeFilter bson.M) (*[]studentModel.Student, error) {
 	studentCollection := mongikClient.MongoClient.Database(constants.DB).Collection(constants.COLLECTION_STUDENT)
-	filter := BuildStudentExportFilter(startYear, endYear, status)
+	filter := withScopeFilter(BuildStudentExportFilter(startYear, endYear, status), scopeFilter)
 
 	cursor, err := studentCollection.Find(context.Background(), filter)
 	if err != nil {
@@ -457,6 +465,10 @@ func GetStudentById(mongikClient *models.Mongik, _id primitive.ObjectID, noCache
 		},
 	},
 		noCache)
+	if err == nil {
+		err = ApplyMemberships(mongikClient, &student, noCache)
+	}
+	student.ResolveRoleSet()
 	return &student, err
 }
 
@@ -540,8 +552,23 @@ func GetAllStudentsOfRole(mongikClient *models.Mongik, role string, noCache bool
 		},
 	},
 		noCache)
+	if err != nil {
+		return &roleStudents, err
+	}
+
+	// A group held outside its membership window does not grant the role
+	activeStudents := make([]model.StudentPopulated, 0, len(roleStudents))
+	for idx := range roleStudents {
+		if err := ApplyMemberships(mongikClient, &roleStudents[idx], noCache); err != nil {
+			return &activeStudents, err
+		}
+		roleStudents[idx].ResolveRoleSet()
+		if roleStudents[idx].RoleSet().Has(role) {
+			activeStudents = append(activeStudents, roleStudents[idx])
+		}
+	}
 
-	return &roleStudents, err
+	return &activeStudents, nil
 }
 
 // delete student profile cache key from Redis/BigCache
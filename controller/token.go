@@ -2,93 +2,97 @@ package controller
 
 import (
 	"fmt"
-	"io"
-	"net/http"
-	"os"
 	"time"
 
 	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
 	"github.com/FrosTiK-SD/auth/util"
-	"github.com/allegro/bigcache/v3"
-	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 )
 
-func GetJWKs(cacheClient *bigcache.BigCache, noCache bool) (*jwk.Set, *string) {
-	// Check if copy is there in the cache
-	var jwkString string
-	var jwkBytes []byte
-
-	if !noCache {
-		jwkBytes, err := cacheClient.Get(constants.GCP_JWKS)
-		if err == nil {
-			fmt.Println("Successfully fetched JWKs from cache")
-			jwkString = string(jwkBytes)
-			jwkSet, err := jwk.ParseString(jwkString)
-			if err != nil {
-				return nil, &constants.ERROR_PARSING_JWK
-			} else {
-				return &jwkSet, nil
-			}
-		}
-	}
-
-	// Fetch the JWKs from GoogleAPIs
-	jwks, err := http.Get("https://www.googleapis.com/service_accounts/v1/jwk/securetoken@system.gserviceaccount.com")
+// Tokens found in the revocation store are rejected even when their signature is valid
+func VerifyToken(keyManager *KeyManager, revocationStore *RevocationStore, idToken string) (*interfaces.Token, *time.Time, *string) {
+	// Read the issuer and kid first so that we know which key to verify the signature with
+	unverifiedJWT, err := jwt.ParseInsecure([]byte(idToken))
 	if err != nil {
-		return nil, &constants.ERROR_FETCH_JWK
+		return nil, nil, &constants.ERROR_INVALID_TOKEN
 	}
-	fmt.Println("Fetched JWKs from GCP")
-
-	// Convert to bytes and them read it as a string
-	jwkBytes, err = io.ReadAll(jwks.Body)
-	if err != nil {
-		return nil, &constants.ERROR_CONVERT_JWT_TO_BYTES
+	jwsMessage, err := jws.Parse([]byte(idToken))
+	if err != nil || len(jwsMessage.Signatures()) == 0 {
+		return nil, nil, &constants.ERROR_INVALID_TOKEN
 	}
 
-	jwkString = string(jwkBytes)
-	jwkSet, err := jwk.ParseString(jwkString)
-	if err != nil {
-		return nil, &constants.ERROR_PARSING_JWK
+	issuer := keyManager.GetIssuer(unverifiedJWT.Issuer())
+	if issuer == nil {
+		return nil, nil, &constants.ERROR_UNTRUSTED_ISSUER
 	}
 
-	// Set the JWKs in the cache
-	if err = cacheClient.Set(constants.GCP_JWKS, []byte(jwkString)); err == nil {
-		fmt.Println("Successfully set JWKs in cache")
-	}
-
-	return &jwkSet, nil
-}
-
-func VerifyToken(cacheClient *bigcache.BigCache, idToken string, defaultJwkSet *jwk.Set, noCache bool) (*string, *time.Time, *string) {
-	jwkSet := defaultJwkSet
-	if !noCache {
-		newJwkSet, jwkParsingError := GetJWKs(cacheClient, noCache)
-		if jwkParsingError != nil {
-			return nil, nil, jwkParsingError
-		}
-		jwkSet = newJwkSet
+	jwkSet, jwkError := keyManager.GetKeySet(issuer.Issuer, jwsMessage.Signatures()[0].ProtectedHeaders().KeyID())
+	if jwkError != nil {
+		return nil, nil, jwkError
 	}
 
 	// Verify the token
-	rawJWT, err := jwt.Parse([]byte(idToken), jwt.WithKeySet(*jwkSet))
+	rawJWT, err := jwt.Parse([]byte(idToken), jwt.WithKeySet(jwkSet, jws.WithInferAlgorithmFromKey(true)))
 	if err != nil {
 		return nil, nil, &constants.ERROR_TOKEN_SIGNATURE_INVALID
 	}
 	exp := rawJWT.Expiration()
 
 	// Validations
-	if time.Since(rawJWT.IssuedAt()) < 0 || time.Since(exp) > 0 || rawJWT.Subject() == "" || rawJWT.Issuer() != fmt.Sprintf("https://securetoken.google.com/%s", os.Getenv(constants.FIREBASE_PROJECT_ID)) || !util.ArrayContains(rawJWT.Audience(), os.Getenv(constants.FIREBASE_PROJECT_ID)) {
+	if time.Since(rawJWT.IssuedAt()) < 0 || time.Since(exp) > 0 || rawJWT.Subject() == "" || rawJWT.Issuer() != issuer.Issuer || !util.ArrayContains(rawJWT.Audience(), issuer.Audience) {
 		return nil, &exp, &constants.ERROR_INVALID_TOKEN
 	}
 
 	// Get the email
-	email, found := rawJWT.Get("email")
+	email, found := rawJWT.Get(issuer.EmailClaim)
 	if !found {
 		return nil, &exp, &constants.ERROR_GETTING_EMAIL
 	}
 
-	emailString := fmt.Sprintf("%v", email)
+	token, tokenErr := getTokenClaims(rawJWT, issuer)
+	if tokenErr != nil {
+		return nil, &exp, tokenErr
+	}
+	token.Email = fmt.Sprintf("%v", email)
+	token.TokenID = GetTokenID(token.Jti, idToken)
+
+	if policyErr := CheckClaimPolicy(issuer, token); policyErr != nil {
+		return nil, &exp, policyErr
+	}
+
+	if revocationStore != nil {
+		revoked, revocationErr := revocationStore.IsRevoked(token)
+		if revocationErr != nil {
+			return nil, &exp, revocationErr
+		}
+		if revoked {
+			return nil, &exp, &constants.ERROR_TOKEN_REVOKED
+		}
+	}
+
+	return token, &exp, nil
+}
+
+// Copies the claims of a verified JWT into a Token
+func getTokenClaims(rawJWT jwt.Token, issuer *interfaces.TrustedIssuer) (*interfaces.Token, *string) {
+	var token interfaces.Token
+
+	privateClaims, err := json.Marshal(rawJWT.PrivateClaims())
+	if err != nil {
+		return nil, &constants.ERROR_INVALID_TOKEN
+	}
+	if err := json.Unmarshal(privateClaims, &token); err != nil {
+		return nil, &constants.ERROR_INVALID_TOKEN
+	}
+
+	token.Iss = rawJWT.Issuer()
+	token.Aud = issuer.Audience
+	token.Sub = rawJWT.Subject()
+	token.Iat = int(rawJWT.IssuedAt().Unix())
+	token.Exp = int(rawJWT.Expiration().Unix())
+	token.Jti = rawJWT.JwtID()
 
-	return &emailString, &exp, nil
+	return &token, nil
 }
@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const testIssuer = "https://issuer.example.com"
+const testAudience = "placement"
+
+// A signing key along with the set an issuer would publish for it
+func newTestSigningKey(t *testing.T, kid string) (jwk.Key, jwk.Set) {
+	t.Helper()
+	rawKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	signKey, err := jwk.FromRaw(rawKey)
+	if err != nil {
+		t.Fatalf("FromRaw() error = %v", err)
+	}
+	signKey.Set(jwk.KeyIDKey, kid)
+	signKey.Set(jwk.AlgorithmKey, jwa.ES256)
+
+	publicKey, err := jwk.PublicKeyOf(signKey)
+	if err != nil {
+		t.Fatalf("PublicKeyOf() error = %v", err)
+	}
+	publicSet := jwk.NewSet()
+	publicSet.AddKey(publicKey)
+	return signKey, publicSet
+}
+
+// Claims of a token the test issuer would accept, which the cases then change
+func testClaims() map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":            testIssuer,
+		"aud":            testAudience,
+		"sub":            "user",
+		"iat":            now.Add(-time.Minute).Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"auth_time":      now.Add(-time.Minute).Unix(),
+		"email":          "someone@example.com",
+		"email_verified": true,
+	}
+}
+
+func signTestToken(t *testing.T, signKey jwk.Key, claims map[string]interface{}) string {
+	t.Helper()
+	token := jwt.New()
+	for name, value := range claims {
+		if err := token.Set(name, value); err != nil {
+			t.Fatalf("Set(%s) error = %v", name, err)
+		}
+	}
+	signedToken, err := jwt.Sign(token, jwt.WithKey(jwa.ES256, signKey))
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	return string(signedToken)
+}
+
+func newTestKeyManager(t *testing.T, issuerSources ...IssuerSource) *KeyManager {
+	t.Helper()
+	keyManager := NewKeyManagerFromSources(issuerSources)
+	t.Cleanup(keyManager.Close)
+	return keyManager
+}
+
+func TestLoadTrustedIssuers(t *testing.T) {
+	issuers, err := LoadTrustedIssuers(writeTestConfig(t, `[
+		{"issuer": "https://securetoken.google.com/placement", "audience": "placement", "jwksUrl": "https://example.com/jwks"},
+		{"issuer": "https://login.example.com", "audience": "placement", "discoveryUrl": "https://login.example.com/.well-known/openid-configuration", "emailClaim": "preferred_username"}
+	]`))
+	if err != nil {
+		t.Fatalf("LoadTrustedIssuers() error = %v", err)
+	}
+	if len(issuers) != 2 {
+		t.Fatalf("LoadTrustedIssuers() loaded %d issuers, want 2", len(issuers))
+	}
+	if issuers[0].EmailClaim != constants.DEFAULT_EMAIL_CLAIM || issuers[1].EmailClaim != "preferred_username" {
+		t.Errorf("EmailClaim = %q and %q, want %q and preferred_username", issuers[0].EmailClaim, issuers[1].EmailClaim, constants.DEFAULT_EMAIL_CLAIM)
+	}
+}
+
+func TestLoadTrustedIssuersErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"invalid json", `[`},
+		{"no issuers", `[]`},
+		{"missing issuer", `[{"audience": "placement", "jwksUrl": "https://example.com/jwks"}]`},
+		{"missing audience", `[{"issuer": "https://example.com", "jwksUrl": "https://example.com/jwks"}]`},
+		{"no keys", `[{"issuer": "https://example.com", "audience": "placement"}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := LoadTrustedIssuers(writeTestConfig(t, test.config)); err == nil {
+				t.Errorf("LoadTrustedIssuers() succeeded, want an error")
+			}
+		})
+	}
+
+	if _, err := LoadTrustedIssuers(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("LoadTrustedIssuers() of a missing file succeeded, want an error")
+	}
+}
+
+func TestVerifyToken(t *testing.T) {
+	signKey, publicSet := newTestSigningKey(t, "key")
+	otherKey, _ := newTestSigningKey(t, "key")
+	keyManager := newTestKeyManager(t,
+		IssuerSource{
+			Issuer: interfaces.TrustedIssuer{Issuer: testIssuer, Audience: testAudience, EmailClaim: constants.DEFAULT_EMAIL_CLAIM},
+			Source: NewStaticJWKSource(publicSet),
+		},
+		IssuerSource{
+			Issuer: interfaces.TrustedIssuer{Issuer: "https://login.example.com", Audience: testAudience, EmailClaim: "preferred_username"},
+			Source: NewStaticJWKSource(publicSet),
+		},
+	)
+
+	tests := []struct {
+		name    string
+		signKey jwk.Key
+		change  map[string]interface{}
+		email   string
+		err     string
+	}{
+		{"valid", signKey, nil, "someone@example.com", ""},
+		{"email claim of the issuer", signKey, map[string]interface{}{
+			"iss": "https://login.example.com", "preferred_username": "other@example.com",
+		}, "other@example.com", ""},
+		{"untrusted issuer", signKey, map[string]interface{}{"iss": "https://evil.example.com"}, "", constants.ERROR_UNTRUSTED_ISSUER},
+		{"signed by another key", otherKey, nil, "", constants.ERROR_TOKEN_SIGNATURE_INVALID},
+		{"another audience", signKey, map[string]interface{}{"aud": "someone-else"}, "", constants.ERROR_INVALID_TOKEN},
+		{"expired", signKey, map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix()}, "", constants.ERROR_TOKEN_SIGNATURE_INVALID},
+		{"no subject", signKey, map[string]interface{}{"sub": ""}, "", constants.ERROR_INVALID_TOKEN},
+		{"no email", signKey, map[string]interface{}{"iss": "https://login.example.com"}, "", constants.ERROR_GETTING_EMAIL},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims := testClaims()
+			for name, value := range test.change {
+				claims[name] = value
+			}
+			if claims["sub"] == "" {
+				delete(claims, "sub")
+			}
+
+			token, _, err := VerifyToken(keyManager, nil, signTestToken(t, test.signKey, claims))
+			if test.err != "" {
+				if err == nil || *err != test.err {
+					t.Errorf("VerifyToken() error = %v, want %s", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyToken() error = %s", *err)
+			}
+			if token.Email != test.email || token.Sub != "user" || token.TokenID == "" {
+				t.Errorf("VerifyToken() = %+v, want the email %s and subject user", token, test.email)
+			}
+		})
+	}
+
+	if _, _, err := VerifyToken(keyManager, nil, "not.a.token"); err == nil || *err != constants.ERROR_INVALID_TOKEN {
+		t.Errorf("VerifyToken() of garbage error = %v, want %s", err, constants.ERROR_INVALID_TOKEN)
+	}
+}
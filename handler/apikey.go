@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (h *Handler) GetAllAPIKeys(ctx *gin.Context) {
+	apiKeys, err := controller.GetAllAPIKeys(h.MongikClient, util.GetNoCache(ctx))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"data":  nil,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  apiKeys,
+		"error": nil,
+	})
+}
+
+func (h *Handler) CreateAPIKey(ctx *gin.Context) {
+	createAPIKeyRequest := interfaces.CreateAPIKeyRequest{}
+
+	if errBinding := ctx.BindJSON(&createAPIKeyRequest); errBinding != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error":   constants.ERROR_INCORRENT_BODY,
+			"message": errBinding,
+		})
+		return
+	}
+
+	admin, exists := ctx.Get(constants.SESSION)
+	adminStudent, ok := admin.(*model.StudentPopulated)
+	if !exists || !ok {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Cannot get student",
+		})
+		return
+	}
+
+	key, apiKey, err := controller.CreateAPIKey(h.MongikClient, createAPIKeyRequest.Name, adminStudent.Id, createAPIKeyRequest.Roles, createAPIKeyRequest.ExpiresAt)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"data":  nil,
+			"error": err,
+		})
+		return
+	}
+
+	h.LogActivityDirect(adminStudent.Id, "CREATE", fmt.Sprintf("Created API key %s (%s)", apiKey.Name, apiKey.Id.Hex()))
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": interfaces.APIKeyResponse{
+			Key:    key,
+			APIKey: apiKey,
+		},
+		"error": nil,
+	})
+}
+
+func (h *Handler) RotateAPIKey(ctx *gin.Context) {
+	keyId, errParse := primitive.ObjectIDFromHex(ctx.GetHeader("id"))
+	if errParse != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "Invaild ObjectID",
+		})
+		return
+	}
+
+	admin, exists := ctx.Get(constants.SESSION)
+	adminStudent, ok := admin.(*model.StudentPopulated)
+	if !exists || !ok {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Cannot get student",
+		})
+		return
+	}
+
+	key, apiKey, err := controller.RotateAPIKey(h.MongikClient, keyId)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"data":  nil,
+			"error": err,
+		})
+		return
+	}
+
+	h.LogActivityDirect(adminStudent.Id, "EDIT", fmt.Sprintf("Rotated API key %s (%s)", apiKey.Name, apiKey.Id.Hex()))
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": interfaces.APIKeyResponse{
+			Key:    key,
+			APIKey: apiKey,
+		},
+		"error": nil,
+	})
+}
+
+func (h *Handler) RevokeAPIKey(ctx *gin.Context) {
+	keyId, errParse := primitive.ObjectIDFromHex(ctx.GetHeader("id"))
+	if errParse != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "Invaild ObjectID",
+		})
+		return
+	}
+
+	admin, exists := ctx.Get(constants.SESSION)
+	adminStudent, ok := admin.(*model.StudentPopulated)
+	if !exists || !ok {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Cannot get student",
+		})
+		return
+	}
+
+	updateResult, err := controller.RevokeAPIKey(h.MongikClient, keyId)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error":   constants.ERROR_MONGO_ERROR,
+			"message": err,
+		})
+		return
+	}
+
+	h.LogActivityDirect(adminStudent.Id, "DELETE", fmt.Sprintf("Revoked API key %s", keyId.Hex()))
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  updateResult,
+		"error": nil,
+	})
+}
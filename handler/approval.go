@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
+	"github.com/FrosTiK-SD/models/company"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The signed in student behind a request or a review. Neither is allowed while impersonating,
+// otherwise an admin could approve their own change as someone else
+func getApprovalActor(ctx *gin.Context) (*model.StudentPopulated, bool) {
+	if _, impersonating := ctx.Get(constants.IMPERSONATION); impersonating {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"data":  nil,
+			"error": constants.ERROR_APPROVAL_WHILE_IMPERSONATING,
+		})
+		return nil, false
+	}
+
+	value, _ := ctx.Get(constants.REAL_PRINCIPAL)
+	actor, ok := value.(*model.StudentPopulated)
+	if !ok || actor == nil {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Cannot get student",
+		})
+		return nil, false
+	}
+	return actor, true
+}
+
+func (h *Handler) requestApproval(ctx *gin.Context, pendingChange *model.PendingChange) bool {
+	requester, ok := getApprovalActor(ctx)
+	if !ok {
+		return false
+	}
+
+	pendingChange.RequestedBy = requester.Id
+	if err := controller.CreatePendingChange(h.MongikClient, pendingChange); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"data":  nil,
+			"error": err,
+		})
+		return false
+	}
+
+	h.LogActivityDirect(requester.Id, "APPROVAL", fmt.Sprintf("Requested approval for %s on %d groups [%s]", pendingChange.Kind, len(pendingChange.Groups)+len(pendingChange.NewGroups), pendingChange.Id.Hex()))
+	return true
+}
+
+// Holds back pushes onto privileged groups until a second admin approves them, returns the requests that apply now
+func (h *Handler) holdPrivilegedAssignments(ctx *gin.Context, assignRequests []interfaces.BatchAssignGroupRequest) ([]interfaces.BatchAssignGroupRequest, []model.PendingChange, bool) {
+	immediate := []interfaces.BatchAssignGroupRequest{}
+	pendingChanges := []model.PendingChange{}
+
+	for _, request := range assignRequests {
+		if request.Action == constants.ACTION_PUSH {
+			privileged, err := controller.HasPrivilegedGroup(h.MongikClient, request.Groups, util.GetNoCache(ctx))
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"error":   constants.ERROR_MONGO_ERROR,
+					"message": err.Error(),
+				})
+				return nil, nil, false
+			}
+			if privileged {
+				pendingChange := model.PendingChange{
+					Kind:       model.PENDING_CHANGE_GROUP_ASSIGN,
+					Groups:     request.Groups,
+					Students:   request.Students,
+					ValidFrom:  request.ValidFrom,
+					ValidUntil: request.ValidUntil,
+				}
+				if !h.requestApproval(ctx, &pendingChange) {
+					return nil, nil, false
+				}
+				pendingChanges = append(pendingChanges, pendingChange)
+				continue
+			}
+		}
+		immediate = append(immediate, request)
+	}
+
+	return immediate, pendingChanges, true
+}
+
+// Same as holdPrivilegedAssignments for pushes of sensitive roles onto groups
+func (h *Handler) holdPrivilegedEdits(ctx *gin.Context, assignRequests []interfaces.AssignRequest) ([]interfaces.AssignRequest, []model.PendingChange, bool) {
+	immediate := []interfaces.AssignRequest{}
+	pendingChanges := []model.PendingChange{}
+
+	for _, request := range assignRequests {
+		if request.Action == constants.ACTION_PUSH && controller.IsSensitive(request.Roles) {
+			pendingChange := model.PendingChange{
+				Kind:   model.PENDING_CHANGE_GROUP_EDIT,
+				Groups: request.Groups,
+				Roles:  request.Roles,
+			}
+			if !h.requestApproval(ctx, &pendingChange) {
+				return nil, nil, false
+			}
+			pendingChanges = append(pendingChanges, pendingChange)
+			continue
+		}
+		immediate = append(immediate, request)
+	}
+
+	return immediate, pendingChanges, true
+}
+
+// Scope changes on groups with sensitive roles wait for a second admin, as they can widen who the roles apply to
+func (h *Handler) holdPrivilegedScopes(ctx *gin.Context, groupIds []primitive.ObjectID, scope *model.Scope) ([]primitive.ObjectID, *model.PendingChange, bool) {
+	groupScopes, err := controller.GetGroupScopes(h.MongikClient, groupIds, util.GetNoCache(ctx))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error":   constants.ERROR_MONGO_ERROR,
+			"message": err.Error(),
+		})
+		return nil, nil, false
+	}
+
+	privileged := map[primitive.ObjectID]struct{}{}
+	for _, groupScope := range groupScopes {
+		if controller.IsSensitive(groupScope.Roles) {
+			privileged[groupScope.Id] = struct{}{}
+		}
+	}
+
+	immediate := []primitive.ObjectID{}
+	held := []primitive.ObjectID{}
+	for _, groupId := range groupIds {
+		if _, found := privileged[groupId]; found {
+			held = append(held, groupId)
+		} else {
+			immediate = append(immediate, groupId)
+		}
+	}
+	if len(held) == 0 {
+		return immediate, nil, true
+	}
+
+	pendingChange := model.PendingChange{
+		Kind:   model.PENDING_CHANGE_GROUP_SCOPE,
+		Groups: held,
+		Scope:  scope,
+	}
+	if !h.requestApproval(ctx, &pendingChange) {
+		return nil, nil, false
+	}
+	return immediate, &pendingChange, true
+}
+
+// New groups that carry sensitive roles are held the same way as pushing those roles onto an existing group
+func (h *Handler) holdPrivilegedGroups(ctx *gin.Context, groups []company.Group) ([]company.Group, *model.PendingChange, bool) {
+	immediate := []company.Group{}
+	held := []company.Group{}
+	for _, group := range groups {
+		if controller.IsSensitive(group.Roles) {
+			held = append(held, group)
+		} else {
+			immediate = append(immediate, group)
+		}
+	}
+	if len(held) == 0 {
+		return immediate, nil, true
+	}
+
+	pendingChange := model.PendingChange{
+		Kind:      model.PENDING_CHANGE_GROUP_CREATE,
+		NewGroups: held,
+	}
+	if !h.requestApproval(ctx, &pendingChange) {
+		return nil, nil, false
+	}
+	return immediate, &pendingChange, true
+}
+
+func (h *Handler) GetPendingChanges(ctx *gin.Context) {
+	pendingChanges, err := controller.GetPendingChanges(h.MongikClient, util.GetNoCache(ctx))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"data":  nil,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  pendingChanges,
+		"error": nil,
+	})
+}
+
+func (h *Handler) ApprovePendingChange(ctx *gin.Context) {
+	h.reviewPendingChange(ctx, true)
+}
+
+func (h *Handler) RejectPendingChange(ctx *gin.Context) {
+	h.reviewPendingChange(ctx, false)
+}
+
+func (h *Handler) reviewPendingChange(ctx *gin.Context, approve bool) {
+	changeId, errParse := primitive.ObjectIDFromHex(ctx.GetHeader("id"))
+	if errParse != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "Invaild ObjectID",
+		})
+		return
+	}
+
+	reviewer, ok := getApprovalActor(ctx)
+	if !ok {
+		return
+	}
+
+	pendingChange, err := controller.ReviewPendingChange(h.MongikClient, changeId, reviewer.Id, approve)
+	if pendingChange == nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"data":  nil,
+			"error": err,
+		})
+		return
+	}
+
+	h.LogActivityDirect(reviewer.Id, "APPROVAL", fmt.Sprintf("Marked %s [%s] requested by %s as %s", pendingChange.Kind, pendingChange.Id.Hex(), pendingChange.RequestedBy.Hex(), pendingChange.Status))
+
+	// The review is recorded even if applying the change partly failed
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusPartialContent, gin.H{
+			"data":  pendingChange,
+			"error": err,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  pendingChange,
+		"error": nil,
+	})
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/models/company"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetApprovalActor(t *testing.T) {
+	admin := studentSession([]string{constants.ROLE_STUDENT, constants.ROLE_ADMIN}, time.Now())
+	impersonating := studentSession([]string{constants.ROLE_STUDENT}, time.Now())
+	impersonating.RealStudent = admin.Student
+	impersonating.Impersonation = &model.Impersonation{}
+
+	tests := []struct {
+		name    string
+		session *Session
+		status  int
+		body    string
+	}{
+		{"admin", admin, http.StatusOK, ""},
+		{"impersonating", impersonating, http.StatusForbidden, constants.ERROR_APPROVAL_WHILE_IMPERSONATING},
+		{"no session", nil, http.StatusForbidden, "Cannot get student"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/api/approval", nil)
+			if test.session != nil {
+				setGinSession(ctx, test.session)
+			}
+
+			actor, ok := getApprovalActor(ctx)
+			if ok != (test.status == http.StatusOK) {
+				t.Fatalf("getApprovalActor() ok = %v, want %v", ok, test.status == http.StatusOK)
+			}
+			if ok && actor != test.session.RealStudent {
+				t.Errorf("getApprovalActor() = %v, want the real student", actor)
+			}
+			if recorder.Code != test.status || !strings.Contains(recorder.Body.String(), test.body) {
+				t.Errorf("response = %d %s, want %d %s", recorder.Code, recorder.Body.String(), test.status, test.body)
+			}
+		})
+	}
+}
+
+// Nothing sensitive is requested, so nothing is held and no approval is stored
+func TestHoldPrivilegedWithoutSensitiveRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/api/group", nil)
+	h := &Handler{}
+
+	edits := []interfaces.AssignRequest{
+		{Action: constants.ACTION_PUSH, Roles: []string{constants.ROLE_GROUP_READ}},
+		{Action: constants.ACTION_PULL, Roles: []string{constants.ROLE_ADMIN}},
+	}
+	immediateEdits, pendingEdits, ok := h.holdPrivilegedEdits(ctx, edits)
+	if !ok || len(immediateEdits) != len(edits) || len(pendingEdits) != 0 {
+		t.Errorf("holdPrivilegedEdits() = %d immediate and %d pending, want every edit applied now", len(immediateEdits), len(pendingEdits))
+	}
+
+	groups := []company.Group{{Roles: []string{constants.ROLE_TPR}}}
+	immediateGroups, pendingGroups, ok := h.holdPrivilegedGroups(ctx, groups)
+	if !ok || len(immediateGroups) != 1 || pendingGroups != nil {
+		t.Errorf("holdPrivilegedGroups() = %d immediate and %v pending, want the group created now", len(immediateGroups), pendingGroups)
+	}
+}
+
+// Sensitive changes need an approval actor, impersonating admins are refused before anything is stored
+func TestHoldPrivilegedWhileImpersonating(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/api/group", nil)
+	session := studentSession([]string{constants.ROLE_STUDENT}, time.Now())
+	session.Impersonation = &model.Impersonation{}
+	setGinSession(ctx, session)
+
+	groups := []company.Group{{Roles: []string{constants.ROLE_ADMIN}}}
+	if _, _, ok := (&Handler{}).holdPrivilegedGroups(ctx, groups); ok {
+		t.Fatalf("holdPrivilegedGroups() ok = true, want the request refused")
+	}
+	if recorder.Code != http.StatusForbidden || !strings.Contains(recorder.Body.String(), constants.ERROR_APPROVAL_WHILE_IMPERSONATING) {
+		t.Errorf("response = %d %s, want %d %s", recorder.Code, recorder.Body.String(), http.StatusForbidden, constants.ERROR_APPROVAL_WHILE_IMPERSONATING)
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+)
+
+type sessionContextKey struct{}
+
+func NewSessionContext(parent context.Context, session *Session) context.Context {
+	return context.WithValue(parent, sessionContextKey{}, session)
+}
+
+// The session the verify middlewares stored in the context of the request
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	session, ok := ctx.Value(sessionContextKey{}).(*Session)
+	return session, ok && session != nil
+}
+
+func GetGinSession(ctx *gin.Context) (*Session, bool) {
+	value, exists := ctx.Get(constants.AUTH_SESSION)
+	session, ok := value.(*Session)
+	return session, exists && ok && session != nil
+}
+
+func GetFiberSession(ctx *fiber.Ctx) (*Session, bool) {
+	session, ok := ctx.Locals(constants.AUTH_SESSION).(*Session)
+	return session, ok && session != nil
+}
+
+// The principal the request was verified as, the impersonated student while impersonating
+func FromContext(ctx context.Context) (model.Principal, bool) {
+	session, ok := SessionFromContext(ctx)
+	if !ok || session.Principal == nil {
+		return nil, false
+	}
+	return session.Principal, true
+}
+
+func StudentFromContext(ctx context.Context) (*model.StudentPopulated, bool) {
+	session, ok := SessionFromContext(ctx)
+	if !ok || session.Student == nil {
+		return nil, false
+	}
+	return session.Student, true
+}
+
+func TokenFromContext(ctx context.Context) (*interfaces.Token, bool) {
+	session, ok := SessionFromContext(ctx)
+	if !ok || session.Token == nil {
+		return nil, false
+	}
+	return session.Token, true
+}
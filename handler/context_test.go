@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Every token belongs to a student holding a role of the same name, so responses tell whose session was seen
+func newPerRequestVerifier(tokens int) *fakeVerifier {
+	verifier := &fakeVerifier{sessions: map[string]*Session{}}
+	for idx := 0; idx < tokens; idx++ {
+		role := fmt.Sprintf("ROLE_%d", idx)
+		verifier.sessions[role] = studentSession([]string{role}, time.Now())
+	}
+	return verifier
+}
+
+func sessionRoles(session *Session, ok bool) string {
+	if !ok {
+		return "no session"
+	}
+	return strings.Join(session.Principal.RoleSet().List(), ",")
+}
+
+func TestGinSessionsArePerRequest(t *testing.T) {
+	const requests = 16
+	h := &Handler{Verifier: newPerRequestVerifier(requests), Config: Config{Mode: REMOTE}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/me", h.GinVerifyStudent, func(ctx *gin.Context) {
+		// Give the other requests time to overwrite a shared session, if there were one
+		time.Sleep(time.Millisecond)
+		ginSession, ok := GetGinSession(ctx)
+		contextSession, contextOk := SessionFromContext(ctx.Request.Context())
+		ctx.String(http.StatusOK, sessionRoles(ginSession, ok)+"|"+sessionRoles(contextSession, contextOk))
+	})
+
+	var wg sync.WaitGroup
+	for idx := 0; idx < requests; idx++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			request := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+			request.Header.Set("token", fmt.Sprintf("ROLE_%d", idx))
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			want := fmt.Sprintf("ROLE_%d|ROLE_%d", idx, idx)
+			if recorder.Body.String() != want {
+				t.Errorf("request %d saw %s, want %s", idx, recorder.Body.String(), want)
+			}
+		}(idx)
+	}
+	wg.Wait()
+}
+
+func TestFiberSessionsArePerRequest(t *testing.T) {
+	const requests = 8
+	h := &Handler{Verifier: newPerRequestVerifier(requests), Config: Config{Mode: REMOTE}}
+
+	app := fiber.New()
+	app.Get("/api/me", h.FiberVerifyStudent, func(ctx *fiber.Ctx) error {
+		time.Sleep(time.Millisecond)
+		fiberSession, ok := GetFiberSession(ctx)
+		contextSession, contextOk := SessionFromContext(ctx.UserContext())
+		return ctx.SendString(sessionRoles(fiberSession, ok) + "|" + sessionRoles(contextSession, contextOk))
+	})
+
+	var wg sync.WaitGroup
+	for idx := 0; idx < requests; idx++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			request := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+			request.Header.Set("token", fmt.Sprintf("ROLE_%d", idx))
+			response, err := app.Test(request)
+			if err != nil {
+				t.Errorf("Test() error = %v", err)
+				return
+			}
+			body, _ := io.ReadAll(response.Body)
+
+			want := fmt.Sprintf("ROLE_%d|ROLE_%d", idx, idx)
+			if string(body) != want {
+				t.Errorf("request %d saw %s, want %s", idx, body, want)
+			}
+		}(idx)
+	}
+	wg.Wait()
+}
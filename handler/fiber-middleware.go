@@ -3,74 +3,163 @@ package handler
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/FrosTiK-SD/auth/constants"
-	"github.com/FrosTiK-SD/auth/controller"
 	"github.com/FrosTiK-SD/auth/interfaces"
-	"github.com/FrosTiK-SD/auth/util"
+	"github.com/FrosTiK-SD/auth/model"
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// For Gin based middlewares
+// For Fiber based middlewares
 func (h *Handler) FiberVerifyStudent(ctx *fiber.Ctx) error {
-	idToken := ctx.Get("token", "")
 	noCache := false
 	if ctx.Get("cache-control") == constants.NO_CACHE {
 		noCache = true
 	}
 
-	email, _, err := controller.VerifyToken(h.MongikClient.CacheClient, idToken, h.JwkSet, noCache)
+	session := h.VerifyStudentSession(ctx.Get("token", ""), ctx.Get(constants.IMPERSONATE_HEADER, ""), ctx.Method(), ctx.Path(), noCache)
+	if session.Error != nil {
+		return session.Error
+	}
+
+	setFiberSession(ctx, session)
+	ctx.Next()
+
+	return nil
+}
 
-	if err != nil {
-		return errors.New(*err)
+// Stores the session in the Locals and in the user context of the request
+func setFiberSession(ctx *fiber.Ctx, session *Session) {
+	ctx.Locals(constants.AUTH_SESSION, session)
+	ctx.Locals(constants.SESSION, session.Principal)
+	if session.Token != nil {
+		ctx.Locals(constants.TOKEN, session.Token)
 	}
-	student, err := controller.GetUserByEmail(h.MongikClient, email, &constants.ROLE_STUDENT, noCache)
-	if err != nil {
-		return errors.New(*err)
+	if session.RealStudent != nil {
+		ctx.Locals(constants.REAL_PRINCIPAL, session.RealStudent)
 	}
+	if session.Impersonation != nil {
+		ctx.Locals(constants.IMPERSONATION, session.Impersonation)
+	}
+	ctx.SetUserContext(NewSessionContext(ctx.UserContext(), session))
+}
 
-	impersonateId := ctx.Get("x-impersonate-student-id", "")
-	if impersonateId == "" {
-		impersonateId = ctx.Get("X-Impersonate-Student-Id", "")
+// Accepts an API key in "Authorization: ApiKey <key>" and otherwise behaves like FiberVerifyStudent
+func (h *Handler) FiberVerifyPrincipal(ctx *fiber.Ctx) error {
+	noCache := false
+	if ctx.Get("cache-control") == constants.NO_CACHE {
+		noCache = true
 	}
 
-	if impersonateId != "" {
-		if util.CheckRoleExists(&student.GroupDetails, constants.ROLE_OPPORTUNITIES_WRITE) {
-			targetObjId, parseErr := primitive.ObjectIDFromHex(impersonateId)
-			if parseErr == nil {
-				targetStudent, targetErr := controller.GetStudentById(h.MongikClient, targetObjId, noCache)
-				if targetErr == nil && targetStudent != nil {
-					h.LogActivityDirect(student.Id, "IMPERSONATION", fmt.Sprintf("Admin %s (%s) impersonating Student %s (%s)", student.FirstName, student.InstituteEmail, targetStudent.FirstName, targetStudent.InstituteEmail))
-					student = targetStudent
-				}
-			}
-		} else {
-			return errors.New("Unauthorized impersonation attempt")
-		}
+	session := h.VerifyPrincipalSession(ctx.Get("Authorization", ""), ctx.Get("token", ""), ctx.Get(constants.IMPERSONATE_HEADER, ""), ctx.Method(), ctx.Path(), noCache)
+	if session.Error != nil {
+		return session.Error
 	}
 
-	ctx.Locals(constants.SESSION, student)
+	setFiberSession(ctx, session)
 	ctx.Next()
 
 	return nil
 }
 
-func (h *RoleCheckerHandler) FiberVerifyRole(ctx *fiber.Ctx) error {
-	entity := ctx.Locals(constants.SESSION)
-	var entityGroups *interfaces.Groups
-	entityBytes, err := json.Marshal(entity)
-	if err != nil {
-		return err
+// Puts the recruiter in the session so that FiberVerifyRole works on recruiter routes
+func (h *Handler) FiberVerifyRecruiter(ctx *fiber.Ctx) error {
+	noCache := false
+	if ctx.Get("cache-control") == constants.NO_CACHE {
+		noCache = true
 	}
-	err = json.Unmarshal(entityBytes, &entityGroups)
-	if err != nil {
-		return err
+
+	session := h.VerifyRecruiterSession(ctx.Get("token", ""), noCache)
+	if session.Error != nil {
+		return session.Error
+	}
+
+	setFiberSession(ctx, session)
+	ctx.Next()
+
+	return nil
+}
+
+// To be used after a verify middleware on sensitive routes. Signing in again resets auth_time
+func (h *Handler) GetFiberRecentAuthHandler(maxAge time.Duration) func(ctx *fiber.Ctx) error {
+	return func(ctx *fiber.Ctx) error {
+		principal, _ := ctx.Locals(constants.SESSION).(model.Principal)
+		token, _ := ctx.Locals(constants.TOKEN).(*interfaces.Token)
+		if err := checkRecentAuth(principal, token, maxAge); err != nil {
+			return errors.New(*err)
+		}
+
+		ctx.Next()
+		return nil
 	}
-	if !util.CheckRoleExists(&entityGroups.Groups, h.Role) {
+}
+
+func (h *RoleCheckerHandler) FiberVerifyRole(ctx *fiber.Ctx) error {
+	principal, ok := ctx.Locals(constants.SESSION).(model.Principal)
+	if !ok {
 		return errors.New(constants.ERROR_ROLE_CHECK_FAILED)
 	}
+	session, _ := GetFiberSession(ctx)
+	if met, unmet := evaluateRoles(h.getExpression(), principal, session); !met {
+		return fmt.Errorf("%s: %s", constants.ERROR_ROLE_CHECK_FAILED, unmet)
+	}
 
 	ctx.Next()
 	return nil
 }
+
+// Fiber version of HandlerVerifyStudentIdToken
+func (h *Handler) FiberHandlerVerifyStudentIdToken(ctx *fiber.Ctx) error {
+	noCache := ctx.Get("cache-control") == constants.NO_CACHE
+
+	session := h.VerifyStudentSession(ctx.Get("token", ""), ctx.Get(constants.IMPERSONATE_HEADER, ""), ctx.Method(), ctx.Path(), noCache)
+	if session.Error != nil {
+		status := fiber.StatusOK
+		if session.Status == fiber.StatusForbidden {
+			status = fiber.StatusForbidden
+		}
+		return ctx.Status(status).JSON(fiber.Map{
+			"data":   nil,
+			"error":  session.ErrorCode,
+			"expire": session.Expire,
+		})
+	}
+
+	response := fiber.Map{
+		"data":          session.Student,
+		"roles":         session.Student.RoleSet().List(),
+		"error":         nil,
+		"expire":        session.Expire,
+		"impersonation": session.Impersonation,
+	}
+	if session.Impersonation != nil {
+		response["realStudent"] = session.RealStudent
+		response["realRoles"] = session.RealStudent.RoleSet().List()
+	}
+	return ctx.JSON(response)
+}
+
+// Fiber version of HandlerVerifyRecruiterIdToken, invalid tokens are answered with 401
+func (h *Handler) FiberHandlerVerifyRecruiterIdToken(ctx *fiber.Ctx) error {
+	noCache := ctx.Get("cache-control") == constants.NO_CACHE
+
+	session := h.VerifyRecruiterSession(ctx.Get("token", ""), noCache)
+	if session.Error != nil && session.Token == nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":  session.ErrorCode,
+			"status": fiber.StatusUnauthorized,
+		})
+	}
+	if session.Error != nil {
+		return ctx.JSON(fiber.Map{
+			"data":  nil,
+			"error": session.ErrorCode,
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data":  session.Principal,
+		"roles": session.Principal.RoleSet().List(),
+	})
+}
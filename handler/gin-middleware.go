@@ -3,6 +3,7 @@ package handler
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/FrosTiK-SD/auth/constants"
 	"github.com/FrosTiK-SD/auth/interfaces"
@@ -13,33 +14,114 @@ import (
 
 // For Gin based middlewares
 func (h *Handler) GinVerifyStudent(ctx *gin.Context) {
+	if h.verifyStudent(ctx) {
+		ctx.Next()
+	}
+}
 
-	// Create a new session
-	currentHandler := Handler{
-		MongikClient: h.MongikClient,
-		JwkSet:       h.JwkSet,
-		Session:      &Session{},
-		Config: Config{
-			Mode: MIDDLEWARE,
-		},
+// Puts the student in the context or aborts, without running the rest of the chain
+func (h *Handler) verifyStudent(ctx *gin.Context) bool {
+	session := h.VerifyStudentSession(ctx.GetHeader("token"), ctx.GetHeader(constants.IMPERSONATE_HEADER), ctx.Request.Method, ctx.Request.URL.Path, util.GetNoCache(ctx))
+	if session.Error != nil {
+		h.respondSessionError(ctx, session)
+		ctx.Abort()
+		return false
 	}
 
-	currentHandler.HandlerVerifyStudentIdToken(ctx)
-	student := currentHandler.Session.Student
+	setGinSession(ctx, session)
+	return true
+}
 
-	if student != nil {
-		ctx.Set(constants.SESSION, student)
+// Stores the session in the gin context and in the context.Context of the request
+func setGinSession(ctx *gin.Context, session *Session) {
+	ctx.Set(constants.AUTH_SESSION, session)
+	ctx.Set(constants.SESSION, session.Principal)
+	if session.Token != nil {
+		ctx.Set(constants.TOKEN, session.Token)
+	}
+	if session.RealStudent != nil {
+		ctx.Set(constants.REAL_PRINCIPAL, session.RealStudent)
+	}
+	if session.Impersonation != nil {
+		ctx.Set(constants.IMPERSONATION, session.Impersonation)
+	}
+	ctx.Request = ctx.Request.WithContext(NewSessionContext(ctx.Request.Context(), session))
+}
+
+// Accepts an API key in "Authorization: ApiKey <key>" and otherwise behaves like GinVerifyStudent
+func (h *Handler) GinVerifyPrincipal(ctx *gin.Context) {
+	if h.verifyPrincipal(ctx) {
 		ctx.Next()
-	} else {
-		ctx.Abort()
 	}
 }
 
-// To be Used only after GinVerifyStudent
+func (h *Handler) verifyPrincipal(ctx *gin.Context) bool {
+	if _, isAPIKey := util.GetAPIKey(ctx.GetHeader("Authorization")); !isAPIKey {
+		return h.verifyStudent(ctx)
+	}
+
+	session := h.VerifyPrincipalSession(ctx.GetHeader("Authorization"), "", "", ctx.Request.Method, ctx.Request.URL.Path, util.GetNoCache(ctx))
+	if session.Error != nil {
+		ctx.AbortWithStatusJSON(session.Status, gin.H{
+			"data":  nil,
+			"error": session.ErrorCode,
+		})
+		return false
+	}
+
+	setGinSession(ctx, session)
+	return true
+}
+
+// Puts the recruiter in the session so that GinVerifyRole works on recruiter routes
+func (h *Handler) GinVerifyRecruiter(ctx *gin.Context) {
+	if h.verifyRecruiter(ctx) {
+		ctx.Next()
+	}
+}
+
+func (h *Handler) verifyRecruiter(ctx *gin.Context) bool {
+	session := h.VerifyRecruiterSession(ctx.GetHeader("token"), util.GetNoCache(ctx))
+	if session.Error != nil {
+		ctx.AbortWithStatusJSON(session.Status, gin.H{
+			"data":  nil,
+			"error": session.ErrorCode,
+		})
+		return false
+	}
+
+	setGinSession(ctx, session)
+	return true
+}
+
+// To be used after a verify middleware on sensitive routes. Signing in again resets auth_time
+func (h *Handler) GetRecentAuthHandler(maxAge time.Duration) func(ctx *gin.Context) {
+	return func(ctx *gin.Context) {
+		session, _ := ctx.Get(constants.SESSION)
+		principal, _ := session.(model.Principal)
+		value, _ := ctx.Get(constants.TOKEN)
+		token, _ := value.(*interfaces.Token)
+
+		if err := checkRecentAuth(principal, token, maxAge); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"message": err,
+				"error":   "Please sign in again to continue",
+			})
+			return
+		}
+	}
+}
+
+// To be Used only after GinVerifyStudent. Every role is required
 func (h *Handler) GetRoleCheckHandlerForStudent(roles ...string) func(ctx *gin.Context) {
+	return h.GetRoleExpressionCheckHandler(util.Roles(roles...))
+}
+
+// To be Used only after a verify middleware, for example with util.AnyOf(util.Role(ROLE_ADMIN), util.Role(ROLE_STUDENT_VERIFY))
+func (h *Handler) GetRoleExpressionCheckHandler(expression util.RoleExpression) func(ctx *gin.Context) {
 	return func(ctx *gin.Context) {
 		value, exists := ctx.Get(constants.SESSION)
-		student, ok := value.(*model.StudentPopulated)
+		principal, ok := value.(model.Principal)
 
 		if !exists || !ok {
 			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
@@ -48,51 +130,41 @@ func (h *Handler) GetRoleCheckHandlerForStudent(roles ...string) func(ctx *gin.C
 			})
 			return
 		}
-		for _, role := range roles {
-			if !util.CheckRoleExists(&student.GroupDetails, role) {
-				ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-					"message": constants.ERROR_ROLE_CHECK_FAILED,
-					"error":   fmt.Sprintf("Student Does not have Role '%s'", role),
-				})
-				return
-			}
+		session, _ := GetGinSession(ctx)
+		if met, unmet := evaluateRoles(expression, principal, session); !met {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"message": constants.ERROR_ROLE_CHECK_FAILED,
+				"error":   fmt.Sprintf("Role requirement '%s' is not met", unmet),
+				"unmet":   unmet,
+			})
+			return
 		}
+
+		// Handlers that act on a target student check it again against the scopes of the principal
+		ctx.Set(constants.ROLE_EXPRESSION, expression)
 	}
 }
 
 func (h *RoleCheckerHandler) GinVerifyRole(ctx *gin.Context) {
 	entity, exists := ctx.Get(constants.SESSION)
-	if !exists {
+	principal, ok := entity.(model.Principal)
+	if !exists || !ok {
 		ctx.AbortWithStatusJSON(200, gin.H{
 			"message": constants.ERROR_ROLE_CHECK_FAILED,
 			"error":   "Entity does not exist",
 		})
 		return
 	}
-	var entityGroups *interfaces.Groups
-	entityBytes, err := json.Marshal(entity)
-	if err != nil {
-		ctx.AbortWithStatusJSON(200, gin.H{
-			"message": constants.ERROR_ROLE_CHECK_FAILED,
-			"error":   err,
-		})
-		return
-	}
-	err = json.Unmarshal(entityBytes, &entityGroups)
-	if err != nil {
-		ctx.AbortWithStatusJSON(200, gin.H{
-			"message": constants.ERROR_ROLE_CHECK_FAILED,
-			"error":   err,
-		})
-		return
-	}
-	if !util.CheckRoleExists(&entityGroups.Groups, h.Role) {
+	session, _ := GetGinSession(ctx)
+	if met, unmet := evaluateRoles(h.getExpression(), principal, session); !met {
 		ctx.AbortWithStatusJSON(200, gin.H{
 			"message": constants.ERROR_ROLE_CHECK_FAILED,
 			"error":   "Role does not exist",
+			"unmet":   unmet,
 		})
 		return
 	}
+	ctx.Set(constants.ROLE_EXPRESSION, h.getExpression())
 
 	ctx.Next()
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/FrosTiK-SD/auth/model"
 	"github.com/FrosTiK-SD/auth/util"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func (h *Handler) GetAllGroups(ctx *gin.Context) {
@@ -40,24 +41,43 @@ func (h *Handler) BatchCreateGroup(ctx *gin.Context) {
 		})
 		return
 	}
-	insertResult, err := controller.BatchCreateGroup(h.MongikClient, batchCreateGroupRequest.Groups)
 
-	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   constants.ERROR_MONGO_ERROR,
-			"message": err,
-		})
+	var roles []string
+	for _, group := range batchCreateGroupRequest.Groups {
+		roles = append(roles, group.Roles...)
+	}
+	if !checkKnownRoles(ctx, roles) {
+		return
+	}
+
+	// Groups with sensitive roles wait for a second admin
+	groups, pendingChange, ok := h.holdPrivilegedGroups(ctx, batchCreateGroupRequest.Groups)
+	if !ok {
 		return
 	}
 
+	var insertResult *mongo.InsertManyResult
+	if len(groups) != 0 {
+		var err error
+		insertResult, err = controller.BatchCreateGroup(h.MongikClient, groups)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   constants.ERROR_MONGO_ERROR,
+				"message": err,
+			})
+			return
+		}
+	}
+
 	admin, exists := ctx.Get(constants.SESSION)
 	if exists {
 		adminStudent := admin.(*model.StudentPopulated)
-		h.LogActivityDirect(adminStudent.Id, "CREATE", fmt.Sprintf("Batch created %d groups", len(batchCreateGroupRequest.Groups)))
+		h.LogActivityDirect(adminStudent.Id, "CREATE", fmt.Sprintf("Batch created %d groups", len(groups)))
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"data": insertResult,
+		"data":    insertResult,
+		"pending": pendingChange,
 	})
 }
 
@@ -74,6 +94,23 @@ func (h *Handler) BatchEditGroup(ctx *gin.Context) {
 		return
 	}
 
+	// Unknown roles can still be pulled so that earlier typos can be cleaned up
+	var roles []string
+	for _, request := range assignRequests {
+		if request.Action == constants.ACTION_PUSH {
+			roles = append(roles, request.Roles...)
+		}
+	}
+	if !checkKnownRoles(ctx, roles) {
+		return
+	}
+
+	// Sensitive roles wait for a second admin
+	assignRequests, pendingChanges, ok := h.holdPrivilegedEdits(ctx, assignRequests)
+	if !ok {
+		return
+	}
+
 	addResult, removeResult, errors := controller.BatchEditGroup(h.MongikClient, assignRequests, noCache)
 
 	if len(*errors) != 0 {
@@ -81,6 +118,7 @@ func (h *Handler) BatchEditGroup(ctx *gin.Context) {
 			"data": gin.H{
 				"addList":    addResult,
 				"removeList": removeResult,
+				"pending":    pendingChanges,
 			},
 			"error": errors,
 		})
@@ -94,6 +132,7 @@ func (h *Handler) BatchEditGroup(ctx *gin.Context) {
 			"data": gin.H{
 				"addList":    addResult,
 				"removeList": removeResult,
+				"pending":    pendingChanges,
 			},
 			"error": nil,
 		})
@@ -151,22 +190,51 @@ func (h *Handler) BatchAssignGroup(ctx *gin.Context) {
 		})
 		return
 	}
+	for idx := range batchAssignGroupRequest {
+		if err := controller.ValidateMembershipWindow(batchAssignGroupRequest[idx].ValidFrom, batchAssignGroupRequest[idx].ValidUntil); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": err,
+			})
+			return
+		}
+	}
+
+	// Privileged groups wait for a second admin
+	batchAssignGroupRequest, pendingChanges, ok := h.holdPrivilegedAssignments(ctx, batchAssignGroupRequest)
+	if !ok {
+		return
+	}
+
 	addList, removeList, errors := controller.BatchAssignGroup(h.MongikClient, batchAssignGroupRequest)
 
+	// Students that lose a group lose its roles, so session tokens with the group baked in are cut off right away
+	admin, exists := ctx.Get(constants.SESSION)
+	revokedBy := ""
+	if adminStudent, ok := admin.(*model.StudentPopulated); exists && ok {
+		revokedBy = adminStudent.InstituteEmail
+	}
+	for idx := range batchAssignGroupRequest {
+		if batchAssignGroupRequest[idx].Action != constants.ACTION_PULL {
+			continue
+		}
+		if err := controller.RevokeGroups(h.RevocationStore, batchAssignGroupRequest[idx].Students, batchAssignGroupRequest[idx].Groups, revokedBy, "Removed from groups"); err != nil {
+			errors = append(errors, fmt.Errorf("%s", *err))
+		}
+	}
+
 	if len(errors) != 0 {
 		ctx.AbortWithStatusJSON(http.StatusPartialContent, gin.H{
 			"data": gin.H{
 				"addList":    addList,
 				"removeList": removeList,
+				"pending":    pendingChanges,
 			},
 			"error": errors,
 		})
 		return
 	}
 
-	admin, exists := ctx.Get(constants.SESSION)
-	if exists {
-		adminStudent := admin.(*model.StudentPopulated)
+	if adminStudent, ok := admin.(*model.StudentPopulated); exists && ok {
 		h.LogActivityDirect(adminStudent.Id, "EDIT", fmt.Sprintf("Batch assigned/unassigned groups for %d students", len(batchAssignGroupRequest)))
 	}
 
@@ -174,6 +242,7 @@ func (h *Handler) BatchAssignGroup(ctx *gin.Context) {
 		"data": gin.H{
 			"addList":    addList,
 			"removeList": removeList,
+			"pending":    pendingChanges,
 		},
 		"error": nil,
 	})
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/util"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// What a gRPC method needs, the gRPC counterpart of a route policy rule
+type GRPCMethodRule struct {
+	Principal  interfaces.PolicyPrincipal
+	Roles      util.RoleExpression
+	RecentAuth bool
+
+	// Methods that only read, which read-only impersonations may call
+	ReadOnly bool
+}
+
+// Keyed by the full method name, for example "/placement.StudentService/GetProfile".
+// Methods without a rule are denied
+type GRPCMethodRules map[string]GRPCMethodRule
+
+// Metadata keys are lower case, the same names as the HTTP headers are used
+func getGRPCMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// The HTTP statuses of the sessions are not precise enough, an expired or revoked token must make the caller
+// sign in again while a missing role must not
+var grpcErrorCodes = map[string]codes.Code{
+	constants.ERROR_CONVERT_JWT_TO_BYTES:         codes.Unauthenticated,
+	constants.ERROR_TOKEN_SIGNATURE_INVALID:      codes.Unauthenticated,
+	constants.ERROR_GETTING_EMAIL:                codes.Unauthenticated,
+	constants.ERROR_INVALID_TOKEN:                codes.Unauthenticated,
+	constants.ERROR_UNTRUSTED_ISSUER:             codes.Unauthenticated,
+	constants.ERROR_TOKEN_REVOKED:                codes.Unauthenticated,
+	constants.ERROR_EMAIL_NOT_VERIFIED:           codes.Unauthenticated,
+	constants.ERROR_SIGN_IN_PROVIDER_NOT_ALLOWED: codes.Unauthenticated,
+	constants.ERROR_AUTH_TIME_MISSING:            codes.Unauthenticated,
+	constants.ERROR_REAUTHENTICATION_REQUIRED:    codes.Unauthenticated,
+	constants.ERROR_INVALID_API_KEY:              codes.Unauthenticated,
+	constants.ERROR_API_KEY_EXPIRED:              codes.Unauthenticated,
+	constants.ERROR_API_KEY_REVOKED:              codes.Unauthenticated,
+
+	constants.ERROR_NOT_A_STUDENT:                   codes.PermissionDenied,
+	constants.ERROR_NOT_A_RECRUITER:                 codes.PermissionDenied,
+	constants.ERROR_RECRUITER_INACTIVE:              codes.PermissionDenied,
+	constants.ERROR_UNAUTHORIZED_IMPERSONATION:      codes.PermissionDenied,
+	constants.ERROR_IMPERSONATION_READ_ONLY:         codes.PermissionDenied,
+	constants.ERROR_IMPERSONATION_PRIVILEGED_TARGET: codes.PermissionDenied,
+	constants.ERROR_INVALID_IMPERSONATION:           codes.PermissionDenied,
+	constants.ERROR_NO_ACTIVE_IMPERSONATION:         codes.PermissionDenied,
+	constants.ERROR_TARGET_OUT_OF_SCOPE:             codes.PermissionDenied,
+
+	constants.ERROR_FETCH_JWK:             codes.Unavailable,
+	constants.ERROR_FETCH_DISCOVERY:       codes.Unavailable,
+	constants.ERROR_REMOTE_VERIFICATION:   codes.Unavailable,
+	constants.ERROR_CHECKING_REVOCATION:   codes.Unavailable,
+	constants.ERROR_MONGO_ERROR:           codes.Unavailable,
+	constants.ERROR_FAILED_FETCH_FROM_DB:  codes.Unavailable,
+	constants.ERROR_RESOLVING_MEMBERSHIPS: codes.Unavailable,
+}
+
+// Errors without a known code are mapped by the status of the session
+func grpcSessionError(session *Session) error {
+	if code, found := grpcErrorCodes[*session.ErrorCode]; found {
+		return status.Error(code, *session.ErrorCode)
+	}
+
+	code := codes.PermissionDenied
+	switch {
+	case session.Status == http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case session.Status >= http.StatusInternalServerError:
+		code = codes.Unavailable
+	}
+	return status.Error(code, *session.ErrorCode)
+}
+
+// Verifies the caller of one gRPC method and returns the context carrying its session
+func (h *Handler) authorizeGRPC(ctx context.Context, fullMethod string, rules GRPCMethodRules) (context.Context, error) {
+	rule, exists := rules[fullMethod]
+	if !exists {
+		return nil, status.Error(codes.PermissionDenied, constants.ERROR_NO_ROUTE_POLICY)
+	}
+	if rule.Principal == interfaces.POLICY_PUBLIC {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	idToken := getGRPCMetadata(md, "token")
+	impersonateId := getGRPCMetadata(md, constants.IMPERSONATE_HEADER)
+	noCache := getGRPCMetadata(md, constants.CACHE_CONTROL_HEADER) == constants.NO_CACHE
+
+	// gRPC calls are HTTP/2 POSTs, only methods marked as reads are checked as a GET for impersonations
+	method := http.MethodPost
+	if rule.ReadOnly {
+		method = http.MethodGet
+	}
+
+	var session *Session
+	switch rule.Principal {
+	case interfaces.POLICY_STUDENT:
+		session = h.VerifyStudentSession(idToken, impersonateId, method, fullMethod, noCache)
+	case interfaces.POLICY_PRINCIPAL:
+		session = h.VerifyPrincipalSession(getGRPCMetadata(md, "authorization"), idToken, impersonateId, method, fullMethod, noCache)
+	case interfaces.POLICY_RECRUITER:
+		session = h.VerifyRecruiterSession(idToken, noCache)
+	default:
+		return nil, status.Error(codes.PermissionDenied, constants.ERROR_NO_ROUTE_POLICY)
+	}
+	if session.Error != nil {
+		return nil, grpcSessionError(session)
+	}
+
+	if rule.Roles != nil {
+		if met, unmet := evaluateRoles(rule.Roles, session.Principal, session); !met {
+			return nil, status.Error(codes.PermissionDenied, "Role requirement '"+unmet+"' is not met")
+		}
+	}
+	if rule.RecentAuth {
+		if err := checkRecentAuth(session.Principal, session.Token, controller.GetSensitiveMaxAuthAge()); err != nil {
+			return nil, status.Error(codes.Unauthenticated, *err)
+		}
+	}
+
+	return NewSessionContext(ctx, session), nil
+}
+
+// Handlers read the caller with FromContext, StudentFromContext or SessionFromContext
+func (h *Handler) GRPCUnaryInterceptor(rules GRPCMethodRules) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := h.authorizeGRPC(ctx, info.FullMethod, rules)
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+// Lets stream handlers see the context with the session in it
+type grpcSessionStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *grpcSessionStream) Context() context.Context {
+	return stream.ctx
+}
+
+// The caller is verified once when the stream is opened
+func (h *Handler) GRPCStreamInterceptor(rules GRPCMethodRules) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+		ctx, err := h.authorizeGRPC(stream.Context(), info.FullMethod, rules)
+		if err != nil {
+			return err
+		}
+		return next(srv, &grpcSessionStream{ServerStream: stream, ctx: ctx})
+	}
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCUnaryInterceptor(t *testing.T) {
+	recruiter := &model.RecruiterModelPopulated{}
+	recruiter.SetResolvedRoles([]string{constants.ROLE_RECRUITER})
+	readOnly := studentSession([]string{constants.ROLE_STUDENT}, time.Now())
+	readOnly.Impersonation = &model.Impersonation{ReadOnly: true}
+	verifier := &fakeVerifier{sessions: map[string]*Session{
+		"student":   studentSession([]string{constants.ROLE_STUDENT}, time.Now()),
+		"editor":    studentSession([]string{constants.ROLE_STUDENT, constants.ROLE_GROUP_EDIT}, time.Now()),
+		"stale":     studentSession([]string{constants.ROLE_STUDENT, constants.ROLE_GROUP_EDIT}, time.Now().Add(-24*time.Hour)),
+		"read-only": readOnly,
+		"recruiter": {Principal: recruiter},
+	}}
+	h := &Handler{Verifier: verifier, Config: Config{Mode: REMOTE}}
+
+	interceptor := h.GRPCUnaryInterceptor(GRPCMethodRules{
+		"/placement.Health/Check":              {Principal: interfaces.POLICY_PUBLIC},
+		"/placement.StudentService/GetProfile": {Principal: interfaces.POLICY_STUDENT, ReadOnly: true},
+		"/placement.StudentService/Apply":      {Principal: interfaces.POLICY_STUDENT},
+		"/placement.GroupService/EditGroup":    {Principal: interfaces.POLICY_STUDENT, Roles: util.Role(constants.ROLE_GROUP_EDIT), RecentAuth: true},
+		"/placement.RecruiterService/GetMe":    {Principal: interfaces.POLICY_RECRUITER},
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		token   string
+		code    codes.Code
+		message string
+	}{
+		{"method without a rule is denied", "/placement.StudentService/Delete", "editor", codes.PermissionDenied, constants.ERROR_NO_ROUTE_POLICY},
+		{"public method", "/placement.Health/Check", "", codes.OK, ""},
+		{"student", "/placement.StudentService/Apply", "student", codes.OK, ""},
+		{"invalid token", "/placement.StudentService/Apply", "missing", codes.Unauthenticated, constants.ERROR_INVALID_TOKEN},
+		{"recruiters are not students", "/placement.StudentService/Apply", "recruiter", codes.PermissionDenied, constants.ERROR_NOT_A_STUDENT},
+		{"role missing", "/placement.GroupService/EditGroup", "student", codes.PermissionDenied, "Role requirement 'GROUP_EDIT' is not met"},
+		{"role held with a recent sign-in", "/placement.GroupService/EditGroup", "editor", codes.OK, ""},
+		{"stale sign-in", "/placement.GroupService/EditGroup", "stale", codes.Unauthenticated, constants.ERROR_REAUTHENTICATION_REQUIRED},
+		{"read-only impersonation of a read", "/placement.StudentService/GetProfile", "read-only", codes.OK, ""},
+		{"read-only impersonation of a write", "/placement.StudentService/Apply", "read-only", codes.PermissionDenied, constants.ERROR_IMPERSONATION_READ_ONLY},
+		{"recruiter", "/placement.RecruiterService/GetMe", "recruiter", codes.OK, ""},
+		{"students are not recruiters", "/placement.RecruiterService/GetMe", "student", codes.PermissionDenied, constants.ERROR_NOT_A_RECRUITER},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("token", test.token))
+			handled := false
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: test.method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				handled = true
+				if _, ok := FromContext(ctx); !ok && test.token != "" {
+					t.Errorf("the handler ran without a session in the context")
+				}
+				return nil, nil
+			})
+
+			if got := status.Code(err); got != test.code {
+				t.Fatalf("code = %s, want %s (%v)", got, test.code, err)
+			}
+			if test.code != codes.OK && status.Convert(err).Message() != test.message {
+				t.Errorf("message = %q, want %q", status.Convert(err).Message(), test.message)
+			}
+			if handled != (test.code == codes.OK) {
+				t.Errorf("handled = %v, want %v", handled, test.code == codes.OK)
+			}
+		})
+	}
+}
+
+func TestGRPCSessionError(t *testing.T) {
+	tests := []struct {
+		name      string
+		errorCode string
+		status    int
+		code      codes.Code
+	}{
+		{"revoked token", constants.ERROR_TOKEN_REVOKED, http.StatusUnauthorized, codes.Unauthenticated},
+		{"known code wins over the status", constants.ERROR_REAUTHENTICATION_REQUIRED, http.StatusForbidden, codes.Unauthenticated},
+		{"out of scope", constants.ERROR_TARGET_OUT_OF_SCOPE, http.StatusForbidden, codes.PermissionDenied},
+		{"database down", constants.ERROR_MONGO_ERROR, http.StatusUnauthorized, codes.Unavailable},
+		{"unknown code with 401", "ERROR_SOMETHING", http.StatusUnauthorized, codes.Unauthenticated},
+		{"unknown code with 502", "ERROR_SOMETHING", http.StatusBadGateway, codes.Unavailable},
+		{"unknown code with 403", "ERROR_SOMETHING", http.StatusForbidden, codes.PermissionDenied},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errorCode := test.errorCode
+			err := grpcSessionError(&Session{ErrorCode: &errorCode, Status: test.status})
+			if got := status.Code(err); got != test.code {
+				t.Errorf("code = %s, want %s", got, test.code)
+			}
+		})
+	}
+}
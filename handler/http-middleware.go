@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/util"
+)
+
+func writeHTTPError(w http.ResponseWriter, status int, body map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func getHTTPNoCache(r *http.Request) bool {
+	return r.Header.Get(constants.CACHE_CONTROL_HEADER) == constants.NO_CACHE
+}
+
+// Passes the request on with the session in its context, or answers with the verification error
+func serveHTTPSession(w http.ResponseWriter, r *http.Request, next http.Handler, session *Session) {
+	if session.Error != nil {
+		writeHTTPError(w, session.Status, map[string]interface{}{
+			"data":   nil,
+			"error":  session.ErrorCode,
+			"expire": session.Expire,
+		})
+		return
+	}
+	next.ServeHTTP(w, r.WithContext(NewSessionContext(r.Context(), session)))
+}
+
+// For net/http and chi based middlewares
+func (h *Handler) HTTPVerifyStudent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := h.VerifyStudentSession(r.Header.Get("token"), r.Header.Get(constants.IMPERSONATE_HEADER), r.Method, r.URL.Path, getHTTPNoCache(r))
+		serveHTTPSession(w, r, next, session)
+	})
+}
+
+// Accepts an API key in "Authorization: ApiKey <key>" and otherwise behaves like HTTPVerifyStudent
+func (h *Handler) HTTPVerifyPrincipal(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := h.VerifyPrincipalSession(r.Header.Get("Authorization"), r.Header.Get("token"), r.Header.Get(constants.IMPERSONATE_HEADER), r.Method, r.URL.Path, getHTTPNoCache(r))
+		serveHTTPSession(w, r, next, session)
+	})
+}
+
+func (h *Handler) HTTPVerifyRecruiter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := h.VerifyRecruiterSession(r.Header.Get("token"), getHTTPNoCache(r))
+		serveHTTPSession(w, r, next, session)
+	})
+}
+
+// To be used after a verify middleware. Every role is required
+func (h *Handler) HTTPRequireRoles(roles ...string) func(http.Handler) http.Handler {
+	return h.HTTPRequireExpression(util.Roles(roles...))
+}
+
+// To be used after a verify middleware, for example with util.AnyOf(util.Role(ROLE_ADMIN), util.Role(ROLE_STUDENT_VERIFY))
+func (h *Handler) HTTPRequireExpression(expression util.RoleExpression) func(http.Handler) http.Handler {
+	return NewRoleExpressionCheckerClient(expression).HTTPVerifyRole
+}
+
+func (h *RoleCheckerHandler) HTTPVerifyRole(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := FromContext(r.Context())
+		if !ok {
+			writeHTTPError(w, http.StatusForbidden, map[string]interface{}{
+				"message": constants.ERROR_ROLE_CHECK_FAILED,
+				"error":   "Entity does not exist",
+			})
+			return
+		}
+		session, _ := SessionFromContext(r.Context())
+		if met, unmet := evaluateRoles(h.getExpression(), principal, session); !met {
+			writeHTTPError(w, http.StatusForbidden, map[string]interface{}{
+				"message": constants.ERROR_ROLE_CHECK_FAILED,
+				"error":   "Role requirement '" + unmet + "' is not met",
+				"unmet":   unmet,
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// To be used after a verify middleware on sensitive routes. Signing in again resets auth_time
+func (h *Handler) GetHTTPRecentAuthHandler(maxAge time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, _ := FromContext(r.Context())
+			token, _ := TokenFromContext(r.Context())
+
+			if err := checkRecentAuth(principal, token, maxAge); err != nil {
+				writeHTTPError(w, http.StatusUnauthorized, map[string]interface{}{
+					"message": err,
+					"error":   "Please sign in again to continue",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
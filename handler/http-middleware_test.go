@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	recruiter := &model.RecruiterModelPopulated{}
+	recruiter.SetResolvedRoles([]string{constants.ROLE_RECRUITER})
+	verifier := &fakeVerifier{sessions: map[string]*Session{
+		"student":   studentSession([]string{constants.ROLE_STUDENT}, time.Now()),
+		"editor":    studentSession([]string{constants.ROLE_STUDENT, constants.ROLE_GROUP_EDIT}, time.Now()),
+		"stale":     studentSession([]string{constants.ROLE_STUDENT, constants.ROLE_GROUP_EDIT}, time.Now().Add(-24*time.Hour)),
+		"recruiter": {Principal: recruiter},
+	}}
+	h := &Handler{Verifier: verifier, Config: Config{Mode: REMOTE}}
+
+	handled := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := FromContext(r.Context()); !ok {
+			t.Errorf("the handler ran without a session in the context")
+		}
+		w.Write([]byte("handled"))
+	})
+	editorOnly := h.HTTPVerifyStudent(h.HTTPRequireRoles(constants.ROLE_GROUP_EDIT)(handled))
+	recentEditor := h.HTTPVerifyStudent(h.HTTPRequireExpression(util.Role(constants.ROLE_GROUP_EDIT))(h.GetHTTPRecentAuthHandler(10 * time.Minute)(handled)))
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		token   string
+		status  int
+		body    string
+	}{
+		{"student", h.HTTPVerifyStudent(handled), "student", http.StatusOK, "handled"},
+		{"invalid token", h.HTTPVerifyStudent(handled), "missing", http.StatusUnauthorized, constants.ERROR_INVALID_TOKEN},
+		{"recruiters are not students", h.HTTPVerifyStudent(handled), "recruiter", http.StatusForbidden, constants.ERROR_NOT_A_STUDENT},
+		{"recruiter", h.HTTPVerifyRecruiter(handled), "recruiter", http.StatusOK, "handled"},
+		{"students are not recruiters", h.HTTPVerifyRecruiter(handled), "student", http.StatusForbidden, constants.ERROR_NOT_A_RECRUITER},
+		{"role missing", editorOnly, "student", http.StatusForbidden, constants.ERROR_ROLE_CHECK_FAILED},
+		{"role held", editorOnly, "editor", http.StatusOK, "handled"},
+		{"recent sign-in", recentEditor, "editor", http.StatusOK, "handled"},
+		{"stale sign-in", recentEditor, "stale", http.StatusUnauthorized, constants.ERROR_REAUTHENTICATION_REQUIRED},
+		{"role check without a verify middleware", h.HTTPRequireRoles(constants.ROLE_STUDENT)(handled), "student", http.StatusForbidden, constants.ERROR_ROLE_CHECK_FAILED},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/api/group", nil)
+			request.Header.Set("token", test.token)
+			recorder := httptest.NewRecorder()
+			test.handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.status {
+				t.Errorf("status = %d, want %d", recorder.Code, test.status)
+			}
+			if !strings.Contains(recorder.Body.String(), test.body) {
+				t.Errorf("body = %s, want it to contain %s", recorder.Body.String(), test.body)
+			}
+			if test.body != "handled" && strings.Contains(recorder.Body.String(), "handled") {
+				t.Errorf("the handler ran on a denied request")
+			}
+		})
+	}
+}
+
+func TestContextAccessors(t *testing.T) {
+	session := studentSession([]string{constants.ROLE_STUDENT}, time.Now())
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		found   bool
+		student bool
+		token   bool
+	}{
+		{"no session", context.Background(), false, false, false},
+		{"nil session", NewSessionContext(context.Background(), nil), false, false, false},
+		{"student session", NewSessionContext(context.Background(), session), true, true, true},
+		{"api key session", NewSessionContext(context.Background(), &Session{Principal: session.Principal}), true, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, ok := FromContext(test.ctx); ok != test.found {
+				t.Errorf("FromContext() ok = %v, want %v", ok, test.found)
+			}
+			if _, ok := StudentFromContext(test.ctx); ok != test.student {
+				t.Errorf("StudentFromContext() ok = %v, want %v", ok, test.student)
+			}
+			if _, ok := TokenFromContext(test.ctx); ok != test.token {
+				t.Errorf("TokenFromContext() ok = %v, want %v", ok, test.token)
+			}
+		})
+	}
+}
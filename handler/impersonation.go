@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Swaps in the target of the active impersonation session of the student. Shared by Gin and Fiber
+// so that every impersonated request is checked and audited the same way
+func (h *Handler) applyImpersonation(student *model.StudentPopulated, impersonateId string, method string, path string, noCache bool) (*model.StudentPopulated, *model.Impersonation, *string) {
+	if impersonateId == "" {
+		return student, nil, nil
+	}
+	if !student.RoleSet().Has(constants.ROLE_OPPORTUNITIES_WRITE) {
+		return nil, nil, &constants.ERROR_UNAUTHORIZED_IMPERSONATION
+	}
+
+	targetId, err := primitive.ObjectIDFromHex(impersonateId)
+	if err != nil {
+		return nil, nil, &constants.ERROR_INVALID_IMPERSONATION
+	}
+
+	impersonation, err := controller.GetActiveImpersonation(h.MongikClient, student.Id)
+	if err != nil || impersonation.Target != targetId {
+		return nil, nil, &constants.ERROR_NO_ACTIVE_IMPERSONATION
+	}
+
+	if err := controller.CheckImpersonationMethod(impersonation, method); err != nil {
+		return nil, nil, err
+	}
+
+	targetStudent, err := controller.GetStudentById(h.MongikClient, targetId, noCache)
+	if err != nil || targetStudent.Id.IsZero() {
+		return nil, nil, &constants.ERROR_INVALID_IMPERSONATION
+	}
+
+	// Checked again on every request as roles of either side may have changed since the session started
+	if err := controller.CheckImpersonationTarget(student, targetStudent); err != nil {
+		return nil, nil, err
+	}
+
+	h.LogActivityDirect(student.Id, "IMPERSONATION", fmt.Sprintf("Admin %s (%s) impersonating Student %s (%s): %s %s [%s]", student.FirstName, student.InstituteEmail, targetStudent.FirstName, targetStudent.InstituteEmail, method, path, impersonation.Id.Hex()))
+
+	return targetStudent, impersonation, nil
+}
+
+// The admin behind the request, who is not the one in SESSION while the impersonation header is sent
+func getImpersonatingAdmin(ctx *gin.Context) (*model.StudentPopulated, bool) {
+	value, exists := ctx.Get(constants.REAL_PRINCIPAL)
+	if !exists {
+		value, exists = ctx.Get(constants.SESSION)
+	}
+	adminStudent, ok := value.(*model.StudentPopulated)
+	if !exists || !ok || adminStudent == nil {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Cannot get student",
+		})
+		return nil, false
+	}
+	return adminStudent, true
+}
+
+func (h *Handler) StartImpersonation(ctx *gin.Context) {
+	startImpersonationRequest := interfaces.StartImpersonationRequest{}
+
+	if errBinding := ctx.BindJSON(&startImpersonationRequest); errBinding != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error":   constants.ERROR_INCORRENT_BODY,
+			"message": errBinding,
+		})
+		return
+	}
+
+	admin, exists := ctx.Get(constants.SESSION)
+	adminStudent, ok := admin.(*model.StudentPopulated)
+	if !exists || !ok {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Cannot get student",
+		})
+		return
+	}
+
+	// Impersonation sessions cannot be chained
+	if _, impersonating := ctx.Get(constants.IMPERSONATION); impersonating {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"data":  nil,
+			"error": constants.ERROR_UNAUTHORIZED_IMPERSONATION,
+		})
+		return
+	}
+
+	target, err := controller.GetStudentById(h.MongikClient, startImpersonationRequest.Target, util.GetNoCache(ctx))
+	if err != nil || target.Id.IsZero() {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return
+	}
+	if !h.authorizeTarget(ctx, target) {
+		return
+	}
+	if err := controller.CheckImpersonationTarget(adminStudent, target); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"data":  nil,
+			"error": err,
+		})
+		return
+	}
+
+	impersonation, startErr := controller.StartImpersonation(h.MongikClient, adminStudent.Id, startImpersonationRequest.Target, startImpersonationRequest.Reason, startImpersonationRequest.ReadOnly, time.Duration(startImpersonationRequest.DurationMinutes)*time.Minute)
+	if startErr != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"data":  nil,
+			"error": startErr,
+		})
+		return
+	}
+
+	h.LogActivityDirect(adminStudent.Id, "IMPERSONATION", fmt.Sprintf("Started impersonating %s until %s (read only: %t): %s", impersonation.Target.Hex(), impersonation.ExpiresAt.Time().UTC().Format(time.RFC3339), impersonation.ReadOnly, impersonation.Reason))
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  impersonation,
+		"error": nil,
+	})
+}
+
+func (h *Handler) StopImpersonation(ctx *gin.Context) {
+	adminStudent, ok := getImpersonatingAdmin(ctx)
+	if !ok {
+		return
+	}
+
+	updateResult, err := controller.StopImpersonation(h.MongikClient, adminStudent.Id)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error":   constants.ERROR_MONGO_ERROR,
+			"message": err,
+		})
+		return
+	}
+
+	if updateResult.ModifiedCount != 0 {
+		h.LogActivityDirect(adminStudent.Id, "IMPERSONATION", "Stopped impersonating")
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  updateResult,
+		"error": nil,
+	})
+}
+
+func (h *Handler) GetActiveImpersonation(ctx *gin.Context) {
+	adminStudent, ok := getImpersonatingAdmin(ctx)
+	if !ok {
+		return
+	}
+
+	impersonation, err := controller.GetActiveImpersonation(h.MongikClient, adminStudent.Id)
+	if err != nil {
+		ctx.JSON(http.StatusOK, gin.H{
+			"data":  nil,
+			"error": constants.ERROR_NO_ACTIVE_IMPERSONATION,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  impersonation,
+		"error": nil,
+	})
+}
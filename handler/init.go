@@ -1,11 +1,16 @@
 package handler
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
 	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/interfaces"
 	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
 	mongik "github.com/FrosTiK-SD/mongik/models"
 	jsoniter "github.com/json-iterator/go"
-	"github.com/lestrrat-go/jwx/v2/jwk"
 )
 
 type Mode string
@@ -15,18 +20,43 @@ var json = jsoniter.ConfigCompatibleWithStandardLibrary
 const (
 	HANDLER    Mode = "HANDLER"
 	MIDDLEWARE Mode = "MIDDLEWARE"
+	REMOTE     Mode = "REMOTE"
 )
 
+// The outcome of verifying one request. Middlewares keep it in the request context, never on the Handler,
+// so concurrent requests cannot see each other's identity
 type Session struct {
-	Error   error
-	Student *model.StudentPopulated
+	Error     error
+	Principal model.Principal
+	Student   *model.StudentPopulated
+	Token     *interfaces.Token
+	Expire    *time.Time
+
+	// Set to the signed in student and their session while impersonating someone else
+	RealStudent   *model.StudentPopulated
+	Impersonation *model.Impersonation
+
+	// The error code behind Error and the status it is reported with
+	ErrorCode *string
+	Status    int
+}
+
+// Verifies sessions somewhere else, such as the HTTP API of a running auth service
+type SessionVerifier interface {
+	VerifyStudentSession(idToken string, impersonateId string, method string, path string, noCache bool) *Session
+	VerifyPrincipalSession(authorization string, idToken string, impersonateId string, method string, path string, noCache bool) *Session
+	VerifyRecruiterSession(idToken string, noCache bool) *Session
 }
 
 type Handler struct {
-	MongikClient *mongik.Mongik
-	JwkSet       *jwk.Set
-	Session      *Session
-	Config       Config
+	MongikClient    *mongik.Mongik
+	KeyManager      *controller.KeyManager
+	SessionIssuer   *controller.SessionIssuer
+	RevocationStore *controller.RevocationStore
+	Config          Config
+
+	// Set in REMOTE mode, the middlewares then verify through it instead of the database
+	Verifier SessionVerifier
 }
 
 type Config struct {
@@ -34,22 +64,60 @@ type Config struct {
 }
 
 type RoleCheckerHandler struct {
-	Role string
+	Role       string
+	Expression util.RoleExpression
 }
 
-func NewAuthClient(mongik *mongik.Mongik) *Handler {
-	defaultJwkSet, _ := controller.GetJWKs(mongik.CacheClient, false)
-	return &Handler{
-		MongikClient: mongik,
-		JwkSet:       defaultJwkSet,
-		Config: Config{
-			Mode: MIDDLEWARE,
-		},
+// Builds the Handler of the auth service and of the services using it as a middleware.
+// A SESSION_SIGNING_KEY that cannot be used fails here instead of on the first refresh
+func NewHandler(mongik *mongik.Mongik, config Config) (*Handler, error) {
+	controller.StartRoleGraphRefresh(mongik)
+
+	keyManager := controller.NewKeyManager(controller.GetTrustedIssuers())
+
+	// Session tokens minted by the auth service verify like any other issuer
+	sessionIssuer, err := controller.NewSessionIssuer()
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", constants.SESSION_SIGNING_KEY, err)
 	}
+	if sessionIssuer != nil {
+		keyManager.AddIssuer(sessionIssuer.GetIssuerSource())
+	}
+
+	return &Handler{
+		MongikClient:    mongik,
+		KeyManager:      keyManager,
+		SessionIssuer:   sessionIssuer,
+		RevocationStore: controller.NewRevocationStore(mongik),
+		Config:          config,
+	}, nil
+}
+
+func NewAuthClient(mongik *mongik.Mongik) (*Handler, error) {
+	return NewHandler(mongik, Config{
+		Mode: MIDDLEWARE,
+	})
 }
 
 func NewRoleCheckerClient(role *string) *RoleCheckerHandler {
 	return &RoleCheckerHandler{
-		Role: *role,
+		Role:       *role,
+		Expression: util.Role(*role),
+	}
+}
+
+// Checkers built without a constructor only have a Role
+func (h *RoleCheckerHandler) getExpression() util.RoleExpression {
+	if h.Expression == nil {
+		return util.Role(h.Role)
+	}
+	return h.Expression
+}
+
+// Checks an expression such as util.AnyOf(util.Role(ROLE_ADMIN), util.Role(ROLE_STUDENT_VERIFY))
+func NewRoleExpressionCheckerClient(expression util.RoleExpression) *RoleCheckerHandler {
+	return &RoleCheckerHandler{
+		Role:       expression.String(),
+		Expression: expression,
 	}
 }
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/util"
+	"github.com/gin-gonic/gin"
+)
+
+// RFC 7662 introspection. The caller authenticates with its client credentials over HTTP Basic
+func (h *Handler) HandlerIntrospectToken(ctx *gin.Context) {
+	clientId, clientSecret, hasCredentials := ctx.Request.BasicAuth()
+	if !hasCredentials || !controller.AuthenticateIntrospectionClient(clientId, clientSecret) {
+		ctx.Header("WWW-Authenticate", `Basic realm="introspect"`)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": constants.ERROR_INVALID_CLIENT,
+		})
+		return
+	}
+
+	idToken := ctx.PostForm("token")
+	if idToken == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": constants.ERROR_INVALID_REQUEST,
+		})
+		return
+	}
+
+	// Introspection results must never be served from a shared cache
+	ctx.Header(constants.CACHE_CONTROL_HEADER, "no-store")
+
+	token, _, err := controller.VerifyToken(h.KeyManager, h.RevocationStore, idToken)
+	if err != nil {
+		ctx.JSON(http.StatusOK, interfaces.IntrospectionResponse{Active: false})
+		return
+	}
+
+	principal, err := h.getPrincipalForToken(token, util.GetNoCache(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusOK, interfaces.IntrospectionResponse{Active: false})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, interfaces.IntrospectionResponse{
+		Active:        true,
+		Sub:           token.Sub,
+		Exp:           token.Exp,
+		Iat:           token.Iat,
+		Iss:           token.Iss,
+		Aud:           token.Aud,
+		Jti:           token.Jti,
+		Email:         principal.PrincipalEmail(),
+		Roles:         principal.RoleSet().List(),
+		PrincipalType: string(principal.PrincipalKind()),
+		PrincipalID:   principal.PrincipalID().Hex(),
+		TokenType:     constants.TOKEN_TYPE_BEARER,
+	})
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/controller"
+)
+
+var startMembershipSweeperOnce sync.Once
+
+// Periodically removes students from groups whose membership has expired, logging each removal
+func (h *Handler) StartMembershipSweeper() {
+	startMembershipSweeperOnce.Do(func() {
+		go func() {
+			h.sweepMemberships()
+			for range time.Tick(constants.MEMBERSHIP_SWEEP_INTERVAL) {
+				h.sweepMemberships()
+			}
+		}()
+	})
+}
+
+func (h *Handler) sweepMemberships() {
+	swept, err := controller.SweepExpiredMemberships(h.MongikClient)
+	if err != nil {
+		fmt.Println("Error sweeping expired memberships:", err)
+	}
+	if len(swept) == 0 {
+		return
+	}
+
+	for _, membership := range swept {
+		h.LogActivityDirect(membership.Student, "EDIT", fmt.Sprintf("Membership of group %s expired at %s", membership.Group.Hex(), membership.ValidUntil.Time().UTC().Format(time.RFC3339)))
+	}
+	if err := controller.RevokeMemberships(h.RevocationStore, swept, "Group membership expired"); err != nil {
+		fmt.Println("Error revoking session tokens of expired memberships:", *err)
+	}
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
+	"github.com/gin-gonic/gin"
+)
+
+// What the signed in principal can do, so that frontends do not have to read groups[].roles themselves
+func (h *Handler) GetMyPermissions(ctx *gin.Context) {
+	session, exists := ctx.Get(constants.SESSION)
+	principal, ok := session.(model.Principal)
+	if !exists || !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Cannot get principal",
+		})
+		return
+	}
+
+	permissions, err := controller.GetPermissions(h.MongikClient, principal, util.GetNoCache(ctx))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"data":  nil,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if value, exists := ctx.Get(constants.IMPERSONATION); exists {
+		permissions.Impersonation, _ = value.(*model.Impersonation)
+	}
+	// Every student session carries its real principal, which only differs while impersonating
+	if value, exists := ctx.Get(constants.REAL_PRINCIPAL); exists {
+		if realStudent, ok := value.(*model.StudentPopulated); ok && realStudent != nil && realStudent.Id != permissions.Id {
+			permissions.RealPrincipal = &realStudent.Id
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  permissions,
+		"error": nil,
+	})
+}
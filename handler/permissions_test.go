@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGetMyPermissions(t *testing.T) {
+	setTestRoutePolicy(t)
+
+	student := studentSession([]string{constants.ROLE_STUDENT}, time.Now())
+	student.Student.Id = primitive.NewObjectID()
+
+	admin := studentSession([]string{constants.ROLE_STUDENT, constants.ROLE_OPPORTUNITIES_WRITE}, time.Now())
+	admin.RealStudent = &model.StudentPopulated{}
+	admin.RealStudent.Id = primitive.NewObjectID()
+	admin.Student.Id = primitive.NewObjectID()
+	admin.Impersonation = &model.Impersonation{Id: primitive.NewObjectID(), ReadOnly: true}
+
+	tests := []struct {
+		name          string
+		session       *Session
+		status        int
+		realPrincipal *primitive.ObjectID
+	}{
+		{"student", student, http.StatusOK, nil},
+		{"impersonating", admin, http.StatusOK, &admin.RealStudent.Id},
+		{"no session", nil, http.StatusUnauthorized, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/api/me/permissions", func(ctx *gin.Context) {
+				if test.session != nil {
+					setGinSession(ctx, test.session)
+				}
+			}, (&Handler{}).GetMyPermissions)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/me/permissions", nil))
+			if recorder.Code != test.status {
+				t.Fatalf("status = %d, want %d", recorder.Code, test.status)
+			}
+			if test.session == nil {
+				return
+			}
+
+			response := struct {
+				Data interfaces.PermissionsResponse `json:"data"`
+			}{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if response.Data.Id != test.session.Student.Id {
+				t.Errorf("Id = %s, want %s", response.Data.Id.Hex(), test.session.Student.Id.Hex())
+			}
+			if (response.Data.RealPrincipal == nil) != (test.realPrincipal == nil) || (test.realPrincipal != nil && *response.Data.RealPrincipal != *test.realPrincipal) {
+				t.Errorf("RealPrincipal = %v, want %v", response.Data.RealPrincipal, test.realPrincipal)
+			}
+			if (response.Data.Impersonation == nil) != (test.session.Impersonation == nil) {
+				t.Errorf("Impersonation = %v, want %v", response.Data.Impersonation, test.session.Impersonation)
+			}
+		})
+	}
+}
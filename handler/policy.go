@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
+	"github.com/gin-gonic/gin"
+)
+
+// Verifies the principal and checks the roles a route needs according to the route policy file.
+// Meant to be installed with r.Use so that every route is covered, routes without a rule are denied
+func (h *Handler) GinEnforcePolicy(ctx *gin.Context) {
+	h.enforcePolicy(ctx, "")
+}
+
+// For the API mounted under a prefix, the prefix is stripped before the route is matched
+func (h *Handler) GetPolicyEnforcer(prefix string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		h.enforcePolicy(ctx, prefix)
+	}
+}
+
+func (h *Handler) enforcePolicy(ctx *gin.Context, prefix string) {
+	// Unknown paths are left to the 404 handler
+	route := ctx.FullPath()
+	if route == "" {
+		ctx.Next()
+		return
+	}
+	route = strings.TrimPrefix(route, prefix)
+
+	rule, expression := controller.GetRoutePolicy().Match(ctx.Request.Method, route)
+	if rule == nil {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"message": constants.ERROR_NO_ROUTE_POLICY,
+			"error":   "No policy allows " + ctx.Request.Method + " " + route,
+		})
+		return
+	}
+
+	verified := true
+	switch rule.Principal {
+	case interfaces.POLICY_PUBLIC:
+		ctx.Next()
+		return
+	case interfaces.POLICY_STUDENT:
+		verified = h.verifyStudent(ctx)
+	case interfaces.POLICY_PRINCIPAL:
+		verified = h.verifyPrincipal(ctx)
+	case interfaces.POLICY_RECRUITER:
+		verified = h.verifyRecruiter(ctx)
+	}
+	if !verified {
+		return
+	}
+
+	if expression != nil {
+		if h.GetRoleExpressionCheckHandler(expression)(ctx); ctx.IsAborted() {
+			return
+		}
+	}
+	if rule.RecentAuth {
+		if h.GetRecentAuthHandler(controller.GetSensitiveMaxAuthAge())(ctx); ctx.IsAborted() {
+			return
+		}
+	}
+
+	ctx.Next()
+}
+
+func (h *Handler) HandlerGetRoutePolicy(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  controller.GetRoutePolicy(),
+		"error": nil,
+	})
+}
+
+// Shows why a student would be let through or denied on a route, without making the request
+func (h *Handler) HandlerExplainAccess(ctx *gin.Context) {
+	noCache := util.GetNoCache(ctx)
+	explainRequest := interfaces.ExplainRequest{}
+
+	if errBinding := ctx.BindJSON(&explainRequest); errBinding != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error":   constants.ERROR_INCORRENT_BODY,
+			"message": errBinding,
+		})
+		return
+	}
+
+	student, err := controller.GetStudentById(h.MongikClient, explainRequest.Student, noCache)
+	if err != nil || student.Id.IsZero() {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return
+	}
+
+	var target *model.StudentPopulated
+	if explainRequest.Target != nil {
+		target, err = controller.GetStudentById(h.MongikClient, *explainRequest.Target, noCache)
+		if err != nil || target.Id.IsZero() {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Target student not found"})
+			return
+		}
+	}
+
+	explanation, err := controller.ExplainAccess(h.MongikClient, student, target, explainRequest.Method, explainRequest.Path, noCache)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"data":  nil,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  explanation,
+		"error": nil,
+	})
+}
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Answers with the session stored for the token and counts the verifications
+type fakeVerifier struct {
+	mutex         sync.Mutex
+	sessions      map[string]*Session
+	verifications int
+}
+
+func (verifier *fakeVerifier) session(idToken string) *Session {
+	verifier.mutex.Lock()
+	defer verifier.mutex.Unlock()
+	verifier.verifications++
+	if session, found := verifier.sessions[idToken]; found {
+		copied := *session
+		return &copied
+	}
+	return &Session{
+		Error:     errors.New(constants.ERROR_INVALID_TOKEN),
+		ErrorCode: &constants.ERROR_INVALID_TOKEN,
+		Status:    http.StatusUnauthorized,
+	}
+}
+
+func (verifier *fakeVerifier) VerifyStudentSession(idToken string, impersonateId string, method string, path string, noCache bool) *Session {
+	session := verifier.session(idToken)
+	if session.Error == nil && session.Principal.PrincipalKind() != model.PRINCIPAL_STUDENT {
+		return &Session{Error: errors.New(constants.ERROR_NOT_A_STUDENT), ErrorCode: &constants.ERROR_NOT_A_STUDENT, Status: http.StatusForbidden}
+	}
+	if session.Error == nil && session.Impersonation != nil {
+		if err := controller.CheckImpersonationMethod(session.Impersonation, method); err != nil {
+			return &Session{Error: errors.New(*err), ErrorCode: err, Status: http.StatusForbidden}
+		}
+	}
+	return session
+}
+
+func (verifier *fakeVerifier) VerifyPrincipalSession(authorization string, idToken string, impersonateId string, method string, path string, noCache bool) *Session {
+	return verifier.VerifyStudentSession(idToken, impersonateId, method, path, noCache)
+}
+
+func (verifier *fakeVerifier) VerifyRecruiterSession(idToken string, noCache bool) *Session {
+	session := verifier.session(idToken)
+	if session.Error == nil && session.Principal.PrincipalKind() != model.PRINCIPAL_RECRUITER {
+		return &Session{Error: errors.New(constants.ERROR_NOT_A_RECRUITER), ErrorCode: &constants.ERROR_NOT_A_RECRUITER, Status: http.StatusForbidden}
+	}
+	return session
+}
+
+const testRoutePolicy = `{
+	"rules": [
+		{"method": "GET", "path": "/api/token/*/verify", "principal": "public"},
+		{"method": "DELETE", "path": "/api/group/:id", "principal": "student", "roles": "ADMIN", "recentAuth": true},
+		{"method": "*", "path": "/api/group/:id", "principal": "student", "roles": "GROUP_EDIT"},
+		{"method": "GET", "path": "/api/recruiter/me", "principal": "recruiter"}
+	]
+}`
+
+func setTestRoutePolicy(t *testing.T) {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(configPath, []byte(testRoutePolicy), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	policy, err := controller.LoadRoutePolicy(configPath)
+	if err != nil {
+		t.Fatalf("LoadRoutePolicy() error = %v", err)
+	}
+
+	previous := controller.GetRoutePolicy()
+	controller.SetRoutePolicy(policy)
+	t.Cleanup(func() { controller.SetRoutePolicy(previous) })
+}
+
+func studentSession(roles []string, authTime time.Time) *Session {
+	student := &model.StudentPopulated{}
+	student.SetResolvedRoles(roles)
+	return &Session{
+		Principal:   student,
+		Student:     student,
+		RealStudent: student,
+		Token:       &interfaces.Token{AuthTime: int(authTime.Unix())},
+	}
+}
+
+func newPolicyTestRouter(h *Handler, prefix string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	api := router.Group(prefix + "/api")
+	if prefix == "" {
+		api.Use(h.GinEnforcePolicy)
+	} else {
+		api.Use(h.GetPolicyEnforcer(prefix))
+	}
+
+	respond := func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "handled")
+	}
+	api.GET("/token/student/verify", respond)
+	api.DELETE("/group/:id", respond)
+	api.PUT("/group/:id", respond)
+	api.GET("/group/:id", respond)
+	api.GET("/recruiter/me", respond)
+	api.POST("/recruiter/me", respond)
+	api.GET("/domain", respond)
+	return router
+}
+
+func TestEnforcePolicy(t *testing.T) {
+	setTestRoutePolicy(t)
+
+	recruiter := &model.RecruiterModelPopulated{}
+	recruiter.SetResolvedRoles([]string{constants.ROLE_RECRUITER})
+	verifier := &fakeVerifier{sessions: map[string]*Session{
+		"student":   studentSession([]string{constants.ROLE_STUDENT}, time.Now()),
+		"editor":    studentSession([]string{constants.ROLE_STUDENT, constants.ROLE_GROUP_EDIT}, time.Now()),
+		"admin":     studentSession([]string{constants.ROLE_STUDENT, constants.ROLE_ADMIN, constants.ROLE_GROUP_EDIT}, time.Now()),
+		"stale":     studentSession([]string{constants.ROLE_STUDENT, constants.ROLE_ADMIN, constants.ROLE_GROUP_EDIT}, time.Now().Add(-24*time.Hour)),
+		"recruiter": {Principal: recruiter},
+	}}
+	h := &Handler{Verifier: verifier, Config: Config{Mode: REMOTE}}
+
+	tests := []struct {
+		name     string
+		prefix   string
+		method   string
+		path     string
+		token    string
+		status   int
+		body     string
+		verified bool
+	}{
+		{"public route skips verification", "", "GET", "/api/token/student/verify", "", http.StatusOK, "handled", false},
+		{"unmatched route is denied", "", "GET", "/api/domain", "admin", http.StatusForbidden, constants.ERROR_NO_ROUTE_POLICY, false},
+		{"unmatched method is denied", "", "POST", "/api/recruiter/me", "recruiter", http.StatusForbidden, constants.ERROR_NO_ROUTE_POLICY, false},
+		{"unknown path is left to the 404 handler", "", "GET", "/api/unknown", "admin", http.StatusNotFound, "", false},
+		{"invalid token", "", "PUT", "/api/group/1", "missing", http.StatusOK, constants.ERROR_INVALID_TOKEN, true},
+		{"role missing", "", "PUT", "/api/group/1", "student", http.StatusForbidden, constants.ERROR_ROLE_CHECK_FAILED, true},
+		{"role held", "", "PUT", "/api/group/1", "editor", http.StatusOK, "handled", true},
+		{"first match wins", "", "DELETE", "/api/group/1", "editor", http.StatusForbidden, constants.ERROR_ROLE_CHECK_FAILED, true},
+		{"recent sign-in", "", "DELETE", "/api/group/1", "admin", http.StatusOK, "handled", true},
+		{"stale sign-in", "", "DELETE", "/api/group/1", "stale", http.StatusUnauthorized, constants.ERROR_REAUTHENTICATION_REQUIRED, true},
+		{"students are not recruiters", "", "GET", "/api/recruiter/me", "admin", http.StatusForbidden, constants.ERROR_NOT_A_RECRUITER, true},
+		{"recruiter", "", "GET", "/api/recruiter/me", "recruiter", http.StatusOK, "handled", true},
+		{"prefix is stripped", "/v1", "PUT", "/v1/api/group/1", "editor", http.StatusOK, "handled", true},
+		{"prefix is stripped before the role check", "/v1", "PUT", "/v1/api/group/1", "student", http.StatusForbidden, constants.ERROR_ROLE_CHECK_FAILED, true},
+		{"prefix is stripped for public routes", "/v1", "GET", "/v1/api/token/student/verify", "", http.StatusOK, "handled", false},
+		{"prefix is stripped before denying", "/v1", "GET", "/v1/api/domain", "admin", http.StatusForbidden, constants.ERROR_NO_ROUTE_POLICY, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier.verifications = 0
+			router := newPolicyTestRouter(h, test.prefix)
+
+			request := httptest.NewRequest(test.method, test.path, nil)
+			if test.token != "" {
+				request.Header.Set("token", test.token)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.status {
+				t.Errorf("status = %d, want %d", recorder.Code, test.status)
+			}
+			if !strings.Contains(recorder.Body.String(), test.body) {
+				t.Errorf("body = %s, want it to contain %s", recorder.Body.String(), test.body)
+			}
+			if test.body != "handled" && strings.Contains(recorder.Body.String(), "handled") {
+				t.Errorf("the route handler ran on a denied request")
+			}
+			if verified := verifier.verifications != 0; verified != test.verified {
+				t.Errorf("verified = %v, want %v", verified, test.verified)
+			}
+		})
+	}
+}
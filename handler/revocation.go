@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Revokes a single token, every token of a user issued until now, or every token of one sign-in
+func (h *Handler) HandlerRevoke(ctx *gin.Context) {
+	var request interfaces.RevokeRequest
+	if errBinding := ctx.ShouldBindJSON(&request); errBinding != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error":   constants.ERROR_INCORRENT_BODY,
+			"message": errBinding.Error(),
+		})
+		return
+	}
+
+	revocation := &model.Revocation{
+		Type:     model.RevocationType(request.Type),
+		TokenID:  request.TokenID,
+		Email:    request.Email,
+		AuthTime: request.AuthTime,
+		Reason:   request.Reason,
+	}
+
+	switch revocation.Type {
+	case model.REVOCATION_TOKEN:
+		if request.Token != "" {
+			tokenRevocation, err := controller.NewTokenRevocation(request.Token)
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"data":  nil,
+					"error": err,
+				})
+				return
+			}
+			revocation.TokenID = tokenRevocation.TokenID
+			revocation.ExpiresAt = tokenRevocation.ExpiresAt
+		}
+		if revocation.TokenID == "" {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"data":  nil,
+				"error": constants.ERROR_INVALID_REVOCATION,
+			})
+			return
+		}
+	case model.REVOCATION_USER:
+		if revocation.Email == "" {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"data":  nil,
+				"error": constants.ERROR_INVALID_REVOCATION,
+			})
+			return
+		}
+	case model.REVOCATION_AUTH_TIME:
+		if revocation.Email == "" || revocation.AuthTime == 0 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"data":  nil,
+				"error": constants.ERROR_INVALID_REVOCATION,
+			})
+			return
+		}
+	default:
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"data":  nil,
+			"error": constants.ERROR_INVALID_REVOCATION,
+		})
+		return
+	}
+
+	admin, exists := ctx.Get(constants.SESSION)
+	adminStudent, _ := admin.(*model.StudentPopulated)
+	if exists && adminStudent != nil {
+		revocation.RevokedBy = adminStudent.InstituteEmail
+	}
+
+	if err := h.RevocationStore.Revoke(revocation); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"data":  nil,
+			"error": err,
+		})
+		return
+	}
+
+	// A revoked user must not be able to mint new session tokens either
+	if revocation.Type == model.REVOCATION_USER {
+		if err := controller.RevokeRefreshSessionsByEmail(h.MongikClient, revocation.Email); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"data":  revocation,
+				"error": err,
+			})
+			return
+		}
+	}
+
+	if adminStudent != nil {
+		h.LogActivityDirect(adminStudent.Id, "REVOKE", fmt.Sprintf("Revoked %s %s%s", revocation.Type, revocation.Email, revocation.TokenID))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  revocation,
+		"error": nil,
+	})
+}
+
+func (h *Handler) HandlerListRevocations(ctx *gin.Context) {
+	revocations, err := h.RevocationStore.List()
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"data":  nil,
+			"error": err,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  revocations,
+		"error": nil,
+	})
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetRoleCatalog(ctx *gin.Context) {
+	catalog, err := controller.GetRoleCatalog(h.MongikClient, util.GetNoCache(ctx))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"data":  nil,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  catalog,
+		"error": nil,
+	})
+}
+
+// While impersonating, a requirement has to be met by the impersonated student and by the real one,
+// so that impersonation never grants a role the actor does not hold
+func evaluateRoles(expression util.RoleExpression, principal model.Principal, session *Session) (bool, string) {
+	if met, unmet := expression.Evaluate(principal.RoleSet()); !met {
+		return false, unmet
+	}
+	if session != nil && session.Impersonation != nil && session.RealStudent != nil {
+		return expression.Evaluate(session.RealStudent.RoleSet())
+	}
+	return true, ""
+}
+
+// Rejects the request with the unknown roles when any role is not in the catalog
+func checkKnownRoles(ctx *gin.Context, roles []string) bool {
+	unknownRoles := controller.GetUnknownRoles(roles)
+	if len(unknownRoles) == 0 {
+		return true
+	}
+
+	ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+		"error":   constants.ERROR_UNKNOWN_ROLE,
+		"message": unknownRoles,
+	})
+	return false
+}
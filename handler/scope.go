@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/FrosTiK-SD/auth/util"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Re-evaluates the role requirement of the route with only the groups whose scope covers the target.
+// Aborts the request and returns false when the target is out of scope
+func (h *Handler) authorizeTarget(ctx *gin.Context, target *model.StudentPopulated) bool {
+	value, exists := ctx.Get(constants.ROLE_EXPRESSION)
+	expression, ok := value.(util.RoleExpression)
+	if !exists || !ok {
+		return true
+	}
+
+	// API clients and recruiters are not bound to scopes
+	session, _ := ctx.Get(constants.SESSION)
+	actor, ok := session.(*model.StudentPopulated)
+	if !ok {
+		return true
+	}
+
+	roleSet, err := controller.GetScopedRoleSet(h.MongikClient, actor, target, util.GetNoCache(ctx))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error":   constants.ERROR_MONGO_ERROR,
+			"message": err.Error(),
+		})
+		return false
+	}
+
+	if met, unmet := expression.Evaluate(roleSet); !met {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"message": constants.ERROR_TARGET_OUT_OF_SCOPE,
+			"error":   "Student is outside the scope of the role requirement",
+			"unmet":   unmet,
+		})
+		return false
+	}
+	return true
+}
+
+// The scope filter for handlers that list or update many students, nil when the actor is not restricted.
+// Aborts the request and returns false when it cannot be resolved
+func (h *Handler) getScopeFilter(ctx *gin.Context) (bson.M, bool) {
+	value, exists := ctx.Get(constants.ROLE_EXPRESSION)
+	expression, ok := value.(util.RoleExpression)
+	if !exists || !ok {
+		return nil, true
+	}
+
+	session, _ := ctx.Get(constants.SESSION)
+	actor, ok := session.(*model.StudentPopulated)
+	if !ok {
+		return nil, true
+	}
+
+	scopeFilter, err := controller.GetScopeFilter(h.MongikClient, actor, expression, util.GetNoCache(ctx))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error":   constants.ERROR_MONGO_ERROR,
+			"message": err.Error(),
+		})
+		return nil, false
+	}
+	return scopeFilter, true
+}
+
+// Same as authorizeTarget for handlers that only have the id of the target
+func (h *Handler) authorizeTargetById(ctx *gin.Context, targetId primitive.ObjectID) bool {
+	if _, exists := ctx.Get(constants.ROLE_EXPRESSION); !exists {
+		return true
+	}
+
+	target, err := controller.GetStudentById(h.MongikClient, targetId, util.GetNoCache(ctx))
+	if err != nil || target.Id.IsZero() {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return false
+	}
+	return h.authorizeTarget(ctx, target)
+}
+
+func (h *Handler) SetGroupScope(ctx *gin.Context) {
+	setGroupScopeRequest := interfaces.SetGroupScopeRequest{}
+
+	if errBinding := ctx.BindJSON(&setGroupScopeRequest); errBinding != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error":   constants.ERROR_INCORRENT_BODY,
+			"message": errBinding,
+		})
+		return
+	}
+
+	// Groups with sensitive roles wait for a second admin
+	groupIds, pendingChange, ok := h.holdPrivilegedScopes(ctx, setGroupScopeRequest.Groups, setGroupScopeRequest.Scope)
+	if !ok {
+		return
+	}
+
+	var updateResult *mongo.UpdateResult
+	if len(groupIds) != 0 {
+		var err error
+		updateResult, err = controller.SetGroupScope(h.MongikClient, groupIds, setGroupScopeRequest.Scope)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   constants.ERROR_MONGO_ERROR,
+				"message": err,
+			})
+			return
+		}
+	}
+
+	admin, exists := ctx.Get(constants.SESSION)
+	if adminStudent, ok := admin.(*model.StudentPopulated); exists && ok {
+		h.LogActivityDirect(adminStudent.Id, "EDIT", fmt.Sprintf("Set the scope of %d groups", len(groupIds)))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":    updateResult,
+		"pending": pendingChange,
+		"error":   nil,
+	})
+}
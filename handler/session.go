@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) issueSession(ctx *gin.Context, student *model.StudentPopulated, authTime int, refreshToken string) {
+	accessToken, _, err := h.SessionIssuer.MintAccessToken(student, authTime)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"data":  nil,
+			"error": err,
+		})
+		return
+	}
+
+	if refreshToken == "" {
+		refreshToken, err = controller.CreateRefreshToken(h.MongikClient, student.Id, authTime)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"data":  nil,
+				"error": err,
+			})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": interfaces.SessionTokenResponse{
+			AccessToken:      accessToken,
+			TokenType:        constants.TOKEN_TYPE_BEARER,
+			ExpiresIn:        int(constants.SESSION_ACCESS_TOKEN_TTL.Seconds()),
+			RefreshToken:     refreshToken,
+			RefreshExpiresIn: int(constants.SESSION_REFRESH_TOKEN_TTL.Seconds()),
+		},
+		"error": nil,
+	})
+}
+
+// Trades an identity provider token for a session token and a refresh token
+func (h *Handler) HandlerExchangeToken(ctx *gin.Context) {
+	if h.SessionIssuer == nil {
+		ctx.JSON(http.StatusNotImplemented, gin.H{
+			"data":  nil,
+			"error": constants.ERROR_SESSIONS_DISABLED,
+		})
+		return
+	}
+
+	token, _, err := controller.VerifyToken(h.KeyManager, h.RevocationStore, ctx.GetHeader("token"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"data":  nil,
+			"error": err,
+		})
+		return
+	}
+
+	// Session tokens are renewed through the refresh token instead
+	if h.SessionIssuer.IsSessionToken(token) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"data":  nil,
+			"error": constants.ERROR_INVALID_TOKEN,
+		})
+		return
+	}
+
+	student, err := controller.GetUserByEmail(h.MongikClient, &token.Email, &constants.ROLE_STUDENT, true)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"data":  nil,
+			"error": err,
+		})
+		return
+	}
+
+	h.issueSession(ctx, student, token.AuthTime, "")
+}
+
+// Rotates the refresh token and mints a new access token with the current roles
+func (h *Handler) HandlerRefreshToken(ctx *gin.Context) {
+	if h.SessionIssuer == nil {
+		ctx.JSON(http.StatusNotImplemented, gin.H{
+			"data":  nil,
+			"error": constants.ERROR_SESSIONS_DISABLED,
+		})
+		return
+	}
+
+	var request interfaces.RefreshTokenRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"data":  nil,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	refreshToken, session, err := controller.RotateRefreshToken(h.MongikClient, request.RefreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"data":  nil,
+			"error": err,
+		})
+		return
+	}
+
+	student, getErr := controller.GetStudentById(h.MongikClient, session.Student, true)
+	if getErr != nil || student.Id.IsZero() {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"data":  nil,
+			"error": constants.ERROR_NOT_A_STUDENT,
+		})
+		return
+	}
+
+	// The sign-in behind the refresh token may have been revoked since
+	revoked, err := h.RevocationStore.IsRevoked(&interfaces.Token{
+		Email:    student.InstituteEmail,
+		AuthTime: session.AuthTime,
+		Iat:      int(time.Now().Unix()),
+	})
+	if err != nil || revoked {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"data":  nil,
+			"error": constants.ERROR_TOKEN_REVOKED,
+		})
+		return
+	}
+
+	h.issueSession(ctx, student, session.AuthTime, refreshToken)
+}
+
+// Public keys for services that verify session tokens on their own
+func (h *Handler) HandlerGetSessionJWKS(ctx *gin.Context) {
+	if h.SessionIssuer == nil {
+		ctx.JSON(http.StatusNotImplemented, gin.H{
+			"error": constants.ERROR_SESSIONS_DISABLED,
+		})
+		return
+	}
+
+	ctx.Header(constants.CACHE_CONTROL_HEADER, "public, max-age=3600")
+	ctx.JSON(http.StatusOK, h.SessionIssuer.PublicKeySet())
+}
@@ -138,13 +138,19 @@ func (h *Handler) GetAllStudents(ctx *gin.Context) {
 		return
 	}
 
+	scopeFilter, ok := h.getScopeFilter(ctx)
+	if !ok {
+		return
+	}
+
 	students, err := controller.SearchStudents(h.MongikClient, controller.StudentSearchFilter{
-		Query:      query,
-		StartYear:  startYear,
-		EndYear:    endYear,
-		Course:     ctx.Query("course"),
-		Department: ctx.Query("department"),
-		Limit:      limit,
+		Query:       query,
+		StartYear:   startYear,
+		EndYear:     endYear,
+		Course:      ctx.Query("course"),
+		Department:  ctx.Query("department"),
+		Limit:       limit,
+		ScopeFilter: scopeFilter,
 	}, noCache)
 
 	if err != nil {
@@ -217,6 +223,9 @@ func (h *Handler) GetStudentById(ctx *gin.Context) {
 		})
 		return
 	}
+	if !h.authorizeTarget(ctx, student) {
+		return
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"data": student,
@@ -302,15 +311,15 @@ func (h *Handler) HandlerRegisterStudentDetails(ctx *gin.Context) {
 		return
 	}
 
-	if email, _, errVerify := controller.VerifyToken(h.MongikClient.CacheClient, idToken, h.JwkSet, true); errVerify != nil {
+	if token, _, errVerify := controller.VerifyToken(h.KeyManager, h.RevocationStore, idToken); errVerify != nil {
 		ctx.AbortWithStatusJSON(401, gin.H{"error": errVerify})
 		return
 	} else {
-		if !util.CheckValidInstituteEmail(*email) {
+		if !util.CheckValidInstituteEmail(token.Email) {
 			ctx.AbortWithStatusJSON(401, gin.H{"error": "not a valid institute email"})
 			return
 		}
-		newStudentDetails.InstituteEmail = *email
+		newStudentDetails.InstituteEmail = token.Email
 	}
 
 	newStudent := studentModel.Student{
@@ -354,6 +363,9 @@ func (h *Handler) HandlerGetStudentProfileById(ctx *gin.Context) {
 		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Student not found"})
 		return
 	}
+	if !h.authorizeTarget(ctx, student) {
+		return
+	}
 
 	studentProfile := interfaces.StudentProfile{}
 	controller.MapStudentToStudentProfile(&studentProfile, &student.Student, true)
@@ -395,6 +407,10 @@ func (h *Handler) HandlerVerifyStudentProfile(ctx *gin.Context) {
 	}
 	adminStudent := admin.(*model.StudentPopulated)
 
+	if !h.authorizeTargetById(ctx, studentId) {
+		return
+	}
+
 	student, err := controller.VerifyStudentProfile(h.MongikClient, studentId, adminStudent.Id)
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -405,7 +421,7 @@ func (h *Handler) HandlerVerifyStudentProfile(ctx *gin.Context) {
 	if student.LastName != nil {
 		lastNameStr = *student.LastName
 	}
-	if util.CheckRoleExists(&adminStudent.GroupDetails, constants.ROLE_ADMIN) {
+	if adminStudent.RoleSet().Has(constants.ROLE_ADMIN) {
 		h.LogActivityDirect(adminStudent.Id, "EDIT", fmt.Sprintf("Verified student profile for %s %s (%s) - Roll No: %d", student.FirstName, lastNameStr, student.InstituteEmail, student.RollNo))
 	}
 
@@ -476,6 +492,10 @@ func (h *Handler) HandlerAdminUpdateStudentDetails(ctx *gin.Context) {
 	}
 	adminStudent := admin.(*model.StudentPopulated)
 
+	if !h.authorizeTargetById(ctx, studentId) {
+		return
+	}
+
 	var studentProfile interfaces.StudentProfile
 	if errBinding := ctx.ShouldBindJSON(&studentProfile); errBinding != nil {
 		ctx.AbortWithStatusJSON(400, gin.H{"error": errBinding.Error()})
@@ -528,7 +548,12 @@ func (h *Handler) HandlerUnverifyStudentProfilesByBatch(ctx *gin.Context) {
 	}
 	adminStudent := admin.(*model.StudentPopulated)
 
-	updatedCount, errs := controller.UnverifyStudentProfilesByBatch(h.MongikClient, req.StartYear, req.EndYear)
+	scopeFilter, ok := h.getScopeFilter(ctx)
+	if !ok {
+		return
+	}
+
+	updatedCount, errs := controller.UnverifyStudentProfilesByBatch(h.MongikClient, req.StartYear, req.EndYear, scopeFilter)
 
 	if len(errs) > 0 {
 		ctx.JSON(http.StatusPartialContent, gin.H{
@@ -561,6 +586,10 @@ func (h *Handler) HandlerAdminUpdateStudentPlacementStatus(ctx *gin.Context) {
 	}
 	adminStudent := admin.(*model.StudentPopulated)
 
+	if !h.authorizeTargetById(ctx, studentId) {
+		return
+	}
+
 	var req interfaces.StudentPlacementStatusUpdate
 	if errBinding := ctx.ShouldBindJSON(&req); errBinding != nil {
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": errBinding.Error()})
@@ -618,8 +647,13 @@ func (h *Handler) HandlerAdminExportStudentsCSV(ctx *gin.Context) {
 		return
 	}
 
+	scopeFilter, ok := h.getScopeFilter(ctx)
+	if !ok {
+		return
+	}
+
 	status := ctx.Query("status")
-	students, err := controller.GetStudentsForExport(h.MongikClient, startYear, endYear, status)
+	students, err := controller.GetStudentsForExport(h.MongikClient, startYear, endYear, status, scopeFilter)
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -3,112 +3,213 @@ package handler
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/FrosTiK-SD/auth/constants"
 	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/interfaces"
+	"github.com/FrosTiK-SD/auth/model"
 	"github.com/FrosTiK-SD/auth/util"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func (h *Handler) HandlerVerifyStudentIdToken(ctx *gin.Context) {
-	idToken := ctx.GetHeader("token")
-	noCache := false
-	if ctx.GetHeader("cache-control") == constants.NO_CACHE {
-		noCache = true
+// Session tokens carry the student id, identity provider tokens are looked up by email
+func (h *Handler) getStudentForToken(token *interfaces.Token, noCache bool) (*model.StudentPopulated, *string) {
+	if h.SessionIssuer.IsSessionToken(token) {
+		return controller.GetStudentBySessionToken(h.MongikClient, token, noCache)
+	}
+	return controller.GetUserByEmail(h.MongikClient, &token.Email, &constants.ROLE_STUDENT, noCache)
+}
+
+// Students are tried first, then recruiters
+func (h *Handler) getPrincipalForToken(token *interfaces.Token, noCache bool) (model.Principal, *string) {
+	student, err := h.getStudentForToken(token, noCache)
+	if err == nil {
+		return student, nil
+	}
+	if h.SessionIssuer.IsSessionToken(token) {
+		return nil, err
+	}
+
+	recruiter, recruiterErr := h.getRecruiterForToken(token, noCache)
+	if recruiterErr != nil {
+		return nil, recruiterErr
 	}
+	return recruiter, nil
+}
 
-	email, exp, err := controller.VerifyToken(h.MongikClient.CacheClient, idToken, h.JwkSet, noCache)
+func (session *Session) fail(err *string, status int) *Session {
+	session.Error = errors.New(*err)
+	session.ErrorCode = err
+	session.Status = status
+	return session
+}
+
+// Verifies the token of one request and resolves impersonation into a new Session.
+// Nothing is written to the Handler, so it is safe to share between concurrent requests
+func (h *Handler) VerifyStudentSession(idToken string, impersonateId string, method string, path string, noCache bool) *Session {
+	if h.Verifier != nil {
+		return h.Verifier.VerifyStudentSession(idToken, impersonateId, method, path, noCache)
+	}
+	session := &Session{}
 
+	token, exp, err := controller.VerifyToken(h.KeyManager, h.RevocationStore, idToken)
+	session.Expire = exp
 	if err != nil {
-		if h.Config.Mode == MIDDLEWARE {
-			h.Session.Error = errors.New(*err)
-		}
-		ctx.JSON(200, gin.H{
-			"student": nil,
-			"expire":  exp,
-			"error":   err,
-		})
-		return
+		return session.fail(err, http.StatusUnauthorized)
 	}
+	session.Token = token
 
-	student, err := controller.GetUserByEmail(h.MongikClient, email, &constants.ROLE_STUDENT, noCache)
+	realStudent, err := h.getStudentForToken(token, noCache)
 	if err != nil {
-		if h.Config.Mode == MIDDLEWARE {
-			h.Session.Error = errors.New(*err)
-		}
-		ctx.JSON(200, gin.H{
-			"data":   nil,
-			"error":  err,
-			"expire": exp,
-		})
-		return
+		return session.fail(err, http.StatusUnauthorized)
 	}
 
-	impersonateId := ctx.GetHeader("x-impersonate-student-id")
-	if impersonateId == "" {
-		impersonateId = ctx.GetHeader("X-Impersonate-Student-Id")
-	}
-
-	if impersonateId != "" {
-		if util.CheckRoleExists(&student.GroupDetails, constants.ROLE_OPPORTUNITIES_WRITE) {
-			targetObjId, parseErr := primitive.ObjectIDFromHex(impersonateId)
-			if parseErr == nil {
-				targetStudent, targetErr := controller.GetStudentById(h.MongikClient, targetObjId, noCache)
-				if targetErr == nil && targetStudent != nil {
-					student = targetStudent
-				}
-			}
-		} else {
-			errMsg := "Unauthorized impersonation attempt"
-			if h.Config.Mode == MIDDLEWARE {
-				h.Session.Error = errors.New(errMsg)
-			}
-			ctx.JSON(http.StatusForbidden, gin.H{
-				"data":   nil,
-				"error":  &errMsg,
-				"expire": exp,
-			})
-			return
-		}
+	student, impersonation, err := h.applyImpersonation(realStudent, impersonateId, method, path, noCache)
+	if err != nil {
+		return session.fail(err, http.StatusForbidden)
 	}
 
-	if h.Config.Mode == MIDDLEWARE {
-		h.Session.Student = student
-	} else {
-		ctx.JSON(200, gin.H{
-			"data":   student,
-			"error":  nil,
-			"expire": exp,
+	session.Principal = student
+	session.Student = student
+	session.RealStudent = realStudent
+	session.Impersonation = impersonation
+	return session
+}
+
+// Takes "Authorization: ApiKey <key>" if present, otherwise the student token
+func (h *Handler) VerifyPrincipalSession(authorization string, idToken string, impersonateId string, method string, path string, noCache bool) *Session {
+	if h.Verifier != nil {
+		return h.Verifier.VerifyPrincipalSession(authorization, idToken, impersonateId, method, path, noCache)
+	}
+	key, isAPIKey := util.GetAPIKey(authorization)
+	if !isAPIKey {
+		return h.VerifyStudentSession(idToken, impersonateId, method, path, noCache)
+	}
+
+	session := &Session{}
+	apiKey, err := controller.AuthenticateAPIKey(h.MongikClient, key)
+	if err != nil {
+		return session.fail(err, http.StatusUnauthorized)
+	}
+	session.Principal = apiKey
+	return session
+}
+
+func (h *Handler) VerifyRecruiterSession(idToken string, noCache bool) *Session {
+	if h.Verifier != nil {
+		return h.Verifier.VerifyRecruiterSession(idToken, noCache)
+	}
+	session := &Session{}
+
+	token, exp, err := controller.VerifyToken(h.KeyManager, h.RevocationStore, idToken)
+	session.Expire = exp
+	if err != nil {
+		return session.fail(err, http.StatusUnauthorized)
+	}
+	session.Token = token
+
+	recruiter, err := h.getRecruiterForToken(token, noCache)
+	if err != nil {
+		return session.fail(err, http.StatusForbidden)
+	}
+	session.Principal = recruiter
+	return session
+}
+
+// Shared by the recent sign-in middlewares. API keys have no interactive sign-in to be recent
+func checkRecentAuth(principal model.Principal, token *interfaces.Token, maxAge time.Duration) *string {
+	if principal != nil && principal.PrincipalKind() == model.PRINCIPAL_API_CLIENT {
+		return nil
+	}
+	return controller.CheckAuthAge(token, maxAge)
+}
+
+// The verify endpoint has always answered failed verification with 200, only impersonation errors are 403
+func (h *Handler) respondSessionError(ctx *gin.Context, session *Session) {
+	status := http.StatusOK
+	if session.Status == http.StatusForbidden {
+		status = http.StatusForbidden
+	}
+	if session.Token == nil {
+		ctx.JSON(status, gin.H{
+			"student": nil,
+			"expire":  session.Expire,
+			"error":   session.ErrorCode,
 		})
+		return
 	}
+	ctx.JSON(status, gin.H{
+		"data":   nil,
+		"error":  session.ErrorCode,
+		"expire": session.Expire,
+	})
 }
 
-func (h *Handler) HandlerVerifyRecruiterIdToken(ctx *gin.Context) {
-	idToken := ctx.GetHeader("token")
+func (h *Handler) HandlerVerifyStudentIdToken(ctx *gin.Context) {
 	noCache := false
 	if ctx.GetHeader("cache-control") == constants.NO_CACHE {
 		noCache = true
 	}
-	email, _, err := controller.VerifyToken(h.MongikClient.CacheClient, idToken, h.JwkSet, noCache)
 
-	if email != nil && *email != "" {
-		recruiter, recErr := controller.GetRecruiterByEmail(h.MongikClient, email, &constants.ROLE_RECRUITER, noCache)
-		if recErr != nil {
+	session := h.VerifyStudentSession(ctx.GetHeader("token"), ctx.GetHeader(constants.IMPERSONATE_HEADER), ctx.Request.Method, ctx.Request.URL.Path, noCache)
+	if session.Error != nil {
+		h.respondSessionError(ctx, session)
+		return
+	}
+
+	// Roles are sent expanded so that remote clients decide with the role graph of the auth service
+	response := gin.H{
+		"data":          session.Student,
+		"roles":         session.Student.RoleSet().List(),
+		"error":         nil,
+		"expire":        session.Expire,
+		"impersonation": session.Impersonation,
+	}
+	// Remote clients check role requirements against the real student as well
+	if session.Impersonation != nil {
+		response["realStudent"] = session.RealStudent
+		response["realRoles"] = session.RealStudent.RoleSet().List()
+	}
+	ctx.JSON(200, response)
+}
+
+// Only active recruiters with the recruiter role get through
+func (h *Handler) getRecruiterForToken(token *interfaces.Token, noCache bool) (*model.RecruiterModelPopulated, *string) {
+	recruiter, err := controller.GetRecruiterByEmail(h.MongikClient, &token.Email, &constants.ROLE_RECRUITER, noCache)
+	if err != nil {
+		return nil, err
+	}
+	if !recruiter.IsActive {
+		return nil, &constants.ERROR_RECRUITER_INACTIVE
+	}
+	return recruiter, nil
+}
+
+func (h *Handler) HandlerVerifyRecruiterIdToken(ctx *gin.Context) {
+	session := h.VerifyRecruiterSession(ctx.GetHeader("token"), util.GetNoCache(ctx))
+
+	if session.Token != nil && session.Token.Email != "" {
+		if session.Error != nil {
 			ctx.JSON(http.StatusOK, gin.H{
-				"data": nil,
-				"error": recErr,
+				"data":  nil,
+				"error": session.ErrorCode,
 			})
 			return
 		}
 		ctx.JSON(http.StatusOK, gin.H{
-			"data": recruiter,
+			"data":  session.Principal,
+			"roles": session.Principal.RoleSet().List(),
 		})
 		return
 	}
 
 	// If email is nil or empty, return error/status like TypeScript
+	var err *string
+	if session.Token == nil {
+		err = session.ErrorCode
+	}
 	status := 500
 	if err != nil && len(*err) >= 4 && (*err)[:4] == "auth" {
 		status = 401
@@ -119,8 +220,13 @@ func (h *Handler) HandlerVerifyRecruiterIdToken(ctx *gin.Context) {
 	})
 }
 
+// In REMOTE mode there are no keys, the sessions cached by the verifier are dropped instead
 func (h *Handler) InvalidateCache(ctx *gin.Context) {
-	h.MongikClient.CacheClient.Delete("GCP_JWKS")
+	if invalidator, ok := h.Verifier.(interface{ InvalidateCache() }); ok {
+		invalidator.InvalidateCache()
+	} else if h.KeyManager != nil {
+		h.KeyManager.RefreshAll()
+	}
 	ctx.JSON(200, gin.H{
 		"message": "Successfully invalidated cache",
 	})
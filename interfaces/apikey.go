@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"github.com/FrosTiK-SD/auth/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string              `json:"name" binding:"required"`
+	Roles     []string            `json:"roles" binding:"required"`
+	ExpiresAt *primitive.DateTime `json:"expiresAt"`
+}
+
+// The key is only ever shown in this response
+type APIKeyResponse struct {
+	Key    string        `json:"key"`
+	APIKey *model.APIKey `json:"apiKey"`
+}
@@ -0,0 +1,26 @@
+package interfaces
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Target is the student the request would act on, for routes that check scoped grants
+type ExplainRequest struct {
+	Student primitive.ObjectID  `json:"student"`
+	Target  *primitive.ObjectID `json:"target,omitempty"`
+	Method  string              `json:"method"`
+	Path    string              `json:"path"`
+}
+
+type ExplainResponse struct {
+	Method             string             `json:"method"`
+	Path               string             `json:"path"`
+	Rule               *RoutePolicyRule   `json:"rule"`
+	Allowed            bool               `json:"allowed"`
+	Reason             string             `json:"reason"`
+	Unmet              string             `json:"unmet,omitempty"`
+	RecentAuthRequired bool               `json:"recentAuthRequired"`
+	Roles              []string           `json:"roles"`
+	Groups             []PermissionsGroup `json:"groups"`
+
+	// Groups the student is not in that would meet the requirement on their own
+	GrantingGroups []PermissionsGroup `json:"grantingGroups,omitempty"`
+}
@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"github.com/FrosTiK-SD/auth/constants"
+	"github.com/FrosTiK-SD/auth/model"
 	"github.com/FrosTiK-SD/models/company"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -24,4 +25,14 @@ type BatchAssignGroupRequest struct {
 	Action   constants.Action     `json:"action" bson:"action"`
 	Groups   []primitive.ObjectID `json:"groups" bson:"groups"`
 	Students []primitive.ObjectID `json:"students" bson:"students"`
+
+	// Pushes with either bound are temporary, the sweeper removes them after validUntil
+	ValidFrom  *primitive.DateTime `json:"validFrom,omitempty" bson:"validFrom,omitempty"`
+	ValidUntil *primitive.DateTime `json:"validUntil,omitempty" bson:"validUntil,omitempty"`
+}
+
+// Scope is applied to every listed group, leaving it out makes them institute wide
+type SetGroupScopeRequest struct {
+	Groups []primitive.ObjectID `json:"groups" bson:"groups"`
+	Scope  *model.Scope         `json:"scope" bson:"scope"`
 }
@@ -0,0 +1,10 @@
+package interfaces
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type StartImpersonationRequest struct {
+	Target          primitive.ObjectID `json:"target" binding:"required"`
+	Reason          string             `json:"reason" binding:"required"`
+	ReadOnly        bool               `json:"readOnly"`
+	DurationMinutes int                `json:"durationMinutes"`
+}
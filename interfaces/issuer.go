@@ -0,0 +1,28 @@
+package interfaces
+
+import "encoding/json"
+
+// Keys are taken from the first of DiscoveryURL, JWKSURL, JWKSFile and JWKS that is set
+type TrustedIssuer struct {
+	Issuer       string          `json:"issuer"`
+	DiscoveryURL string          `json:"discoveryUrl,omitempty"`
+	JWKSURL      string          `json:"jwksUrl,omitempty"`
+	JWKSFile     string          `json:"jwksFile,omitempty"`
+	JWKS         json.RawMessage `json:"jwks,omitempty"`
+	Audience     string          `json:"audience"`
+	EmailClaim   string          `json:"emailClaim,omitempty"`
+
+	// Claim policies. Unset ones fall back to the defaults from the environment
+	RequireEmailVerified     *bool    `json:"requireEmailVerified,omitempty"`
+	AllowedSignInProviders   []string `json:"allowedSignInProviders,omitempty"`
+	InstituteSignInProviders []string `json:"instituteSignInProviders,omitempty"`
+
+	// Tokens minted by this service had their claims checked when they were exchanged
+	FirstParty bool `json:"-"`
+}
+
+// Subset of the OpenID Connect discovery document we care about
+type OpenIDConfiguration struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
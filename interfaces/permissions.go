@@ -0,0 +1,32 @@
+package interfaces
+
+import (
+	"github.com/FrosTiK-SD/auth/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// A group of the principal, roles are only granted on students matching the scope
+type PermissionsGroup struct {
+	Id    primitive.ObjectID `json:"_id"`
+	Name  string             `json:"name"`
+	Roles []string           `json:"roles"`
+	Scope *model.Scope       `json:"scope,omitempty"`
+}
+
+// A route of the route policy the principal passes. Routes needing a recent sign-in may still ask for one
+type AllowedRoute struct {
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	RecentAuth bool   `json:"recentAuth,omitempty"`
+}
+
+type PermissionsResponse struct {
+	Id            primitive.ObjectID   `json:"_id"`
+	Kind          model.PrincipalKind  `json:"kind"`
+	Email         string               `json:"email,omitempty"`
+	Roles         []string             `json:"roles"`
+	Groups        []PermissionsGroup   `json:"groups"`
+	Impersonation *model.Impersonation `json:"impersonation,omitempty"`
+	RealPrincipal *primitive.ObjectID  `json:"realPrincipal,omitempty"`
+	Routes        []AllowedRoute       `json:"routes"`
+}
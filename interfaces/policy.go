@@ -0,0 +1,29 @@
+package interfaces
+
+import "github.com/FrosTiK-SD/auth/util"
+
+// Who has to be signed in for a route, public routes skip verification and role checks
+type PolicyPrincipal string
+
+const (
+	POLICY_PUBLIC    PolicyPrincipal = "public"
+	POLICY_STUDENT   PolicyPrincipal = "student"
+	POLICY_PRINCIPAL PolicyPrincipal = "principal"
+	POLICY_RECRUITER PolicyPrincipal = "recruiter"
+)
+
+// Method is an HTTP method or "*", path is a Gin route pattern and may use path.Match globs
+type RoutePolicyRule struct {
+	Method     string                     `json:"method"`
+	Path       string                     `json:"path"`
+	Principal  PolicyPrincipal            `json:"principal"`
+	Roles      *util.RoleExpressionConfig `json:"roles,omitempty"`
+	RecentAuth bool                       `json:"recentAuth,omitempty"`
+
+	// Filled in when the policy is loaded so the dump shows what is enforced
+	Expression string `json:"expression,omitempty"`
+}
+
+type RoutePolicyFile struct {
+	Rules []RoutePolicyRule `json:"rules"`
+}
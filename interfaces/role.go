@@ -0,0 +1,10 @@
+package interfaces
+
+// A known role, the routes that check it and the roles it implies
+type RoleCatalogEntry struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Implies     []string `json:"implies"`
+	Routes      []string `json:"routes"`
+	BuiltIn     bool     `json:"builtIn"`
+}
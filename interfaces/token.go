@@ -12,7 +12,17 @@ type Token struct {
 	Exp           int    `json:"exp"`
 	Email         string `json:"email"`
 	EmailVerified bool   `json:"email_verified"`
-	Firebase      struct {
+	Jti           string `json:"jti,omitempty"`
+
+	// The jti, or a hash of the raw token when the issuer sets none
+	TokenID string `json:"-"`
+
+	// Only present on session tokens issued by this service
+	Roles    []string `json:"roles,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+	TokenUse string   `json:"token_use,omitempty"`
+
+	Firebase struct {
 		Identities struct {
 			GoogleCom []string `json:"google.com"`
 			Email     []string `json:"email"`
@@ -20,3 +30,41 @@ type Token struct {
 		SignInProvider string `json:"sign_in_provider"`
 	} `json:"firebase"`
 }
+
+type SessionTokenResponse struct {
+	AccessToken      string `json:"accessToken"`
+	TokenType        string `json:"tokenType"`
+	ExpiresIn        int    `json:"expiresIn"`
+	RefreshToken     string `json:"refreshToken"`
+	RefreshExpiresIn int    `json:"refreshExpiresIn"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// Exactly one of the token, the user or the sign-in is revoked
+type RevokeRequest struct {
+	Type     string `json:"type" binding:"required"`
+	TokenID  string `json:"tokenId"`
+	Token    string `json:"token"`
+	Email    string `json:"email"`
+	AuthTime int    `json:"authTime"`
+	Reason   string `json:"reason"`
+}
+
+// Shaped after RFC 7662. Inactive tokens only carry active=false
+type IntrospectionResponse struct {
+	Active        bool     `json:"active"`
+	Sub           string   `json:"sub,omitempty"`
+	Exp           int      `json:"exp,omitempty"`
+	Iat           int      `json:"iat,omitempty"`
+	Iss           string   `json:"iss,omitempty"`
+	Aud           string   `json:"aud,omitempty"`
+	Jti           string   `json:"jti,omitempty"`
+	Email         string   `json:"email,omitempty"`
+	Roles         []string `json:"roles,omitempty"`
+	PrincipalType string   `json:"principal_type,omitempty"`
+	PrincipalID   string   `json:"principal_id,omitempty"`
+	TokenType     string   `json:"token_type,omitempty"`
+}
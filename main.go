@@ -2,12 +2,13 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"strconv"
 
 	"github.com/FrosTiK-SD/auth/constants"
-	"github.com/FrosTiK-SD/auth/controller"
 	"github.com/FrosTiK-SD/auth/handler"
+	"github.com/FrosTiK-SD/auth/routes"
 	"github.com/FrosTiK-SD/auth/util"
 	"github.com/FrosTiK-SD/mongik"
 	mongikConstants "github.com/FrosTiK-SD/mongik/constants"
@@ -44,83 +45,27 @@ func main() {
 		FallbackToDefault: true,
 	})
 
-	// Initialie default JWKs
-	defaultJwkSet, jwkSetRetrieveError := controller.GetJWKs(mongikClient.CacheClient, true)
-	if jwkSetRetrieveError != nil {
-		fmt.Println("Error retrieving JWKs")
-	}
-
 	r.Use(cors.New(util.DefaultCors()))
 
-	handler := &handler.Handler{
-		MongikClient: mongikClient,
-		JwkSet:       defaultJwkSet,
-		Config: handler.Config{
-			Mode: handler.HANDLER,
-		},
-		Session: &handler.Session{},
-	}
-
-	token := r.Group("/api/token")
-	{
-		token.GET("/verify", handler.HandlerVerifyRecruiterIdToken)
-		token.GET("/student/verify", handler.HandlerVerifyStudentIdToken)
-		token.GET("/invalidate_cache", handler.InvalidateCache)
-	}
-
-	student := r.Group("/api/student")
-	{
-		student.GET("", handler.GinVerifyStudent, handler.GetRoleCheckHandlerForStudent(constants.ROLE_OPPORTUNITIES_WRITE), handler.GetAllStudents)
-		student.GET("/id", handler.GinVerifyStudent,handler.GetRoleCheckHandlerForStudent(constants.ROLE_OPPORTUNITIES_WRITE), handler.GetStudentById)
-		student.GET("/tpr/all", handler.GinVerifyStudent, handler.GetRoleCheckHandlerForStudent(constants.ROLE_ADMIN), handler.GetAllTprs)
-		student.GET("/tprLogin", handler.GinVerifyStudent, handler.GetRoleCheckHandlerForStudent(constants.ROLE_TPR), handler.HandlerTprLogin)
-		student.PUT("/update", handler.GinVerifyStudent, handler.HandlerUpdateStudentDetails)
-
-		student.GET("/profile", handler.GinVerifyStudent, handler.HandlerGetStudentProfile)
-		student.PUT("/profile", handler.GinVerifyStudent, handler.HandlerUpdateStudentProfile)
-		student.GET("/profile/id", handler.GinVerifyStudent, handler.GetRoleCheckHandlerForStudent(constants.ROLE_STUDENT_VERIFY), handler.HandlerGetStudentProfileById)
-		student.PUT("/profile/verify", handler.GinVerifyStudent, handler.GetRoleCheckHandlerForStudent(constants.ROLE_STUDENT_VERIFY), handler.HandlerVerifyStudentProfile)
-		student.POST("/register", handler.HandlerRegisterStudentDetails)
-		student.GET("/admin/profile/id", handler.GinVerifyStudent, handler.GetRoleCheckHandlerForStudent(constants.ROLE_ADMIN), handler.HandlerGetStudentProfileById)
-		student.PUT("/admin/update", handler.GinVerifyStudent, handler.GetRoleCheckHandlerForStudent(constants.ROLE_ADMIN), handler.HandlerAdminUpdateStudentDetails)
-		student.PUT("/admin/status", handler.GinVerifyStudent, handler.GetRoleCheckHandlerForStudent(constants.ROLE_OPPORTUNITIES_WRITE), handler.HandlerAdminUpdateStudentPlacementStatus)
-		student.GET("/admin/export/csv", handler.GinVerifyStudent, handler.GetRoleCheckHandlerForStudent(constants.ROLE_OPPORTUNITIES_WRITE), handler.HandlerAdminExportStudentsCSV)
-		student.GET("/admin/csv", handler.GinVerifyStudent, handler.GetRoleCheckHandlerForStudent(constants.ROLE_OPPORTUNITIES_WRITE), handler.HandlerAdminExportStudentsCSV)
-		student.PUT("/admin/unverify-batch", handler.GinVerifyStudent, handler.GetRoleCheckHandlerForStudent(constants.ROLE_ADMIN), handler.HandlerUnverifyStudentProfilesByBatch)
-	}
-
-	group := r.Group("/api/group", handler.GinVerifyStudent)
-	{
-		group.GET("", handler.GetRoleCheckHandlerForStudent(constants.ROLE_GROUP_READ), handler.GetAllGroups)
-		group.POST("/batch", handler.GetRoleCheckHandlerForStudent(constants.ROLE_GROUP_CREATE), handler.BatchCreateGroup)
-		group.PUT("/batch/edit", handler.GetRoleCheckHandlerForStudent(constants.ROLE_GROUP_EDIT), handler.BatchEditGroup)
-		group.DELETE("/batch/delete", handler.GetRoleCheckHandlerForStudent(constants.ROLE_GROUP_DELETE), handler.BatchDeleteGroup)
-		group.POST("/batch/assign", handler.GetRoleCheckHandlerForStudent(constants.ROLE_GROUP_ASSIGN), handler.BatchAssignGroup)
-	}
-
-	domain := r.Group("/api/domain", handler.GinVerifyStudent)
-	{
-		domain.GET("", handler.GetRoleCheckHandlerForStudent(constants.ROLE_DOMAIN_ALL_READ), handler.GetAllDomains)
-		domain.GET("/id", handler.GetRoleCheckHandlerForStudent(constants.ROLE_DOMAIN_ALL_READ), handler.GetDomainById)
-		domain.POST("/batch", handler.GetRoleCheckHandlerForStudent(constants.ROLE_DOMAIN_CREATE), handler.BatchCreateDomain)
-		domain.PUT("/id", handler.GetRoleCheckHandlerForStudent(constants.ROLE_DOMAIN_EDIT), handler.EditDomainById)
-		domain.DELETE("/id", handler.GetRoleCheckHandlerForStudent(constants.ROLE_DOMAIN_DELETE), handler.DeleteDomainById)
+	// Keeps the JWKs of every trusted issuer in memory and refreshes the role graph
+	handler, err := handler.NewHandler(mongikClient, handler.Config{
+		Mode: handler.HANDLER,
+	})
+	if err != nil {
+		log.Fatalln("Unable to create the handler:", err)
 	}
 
-	companies := r.Group("/api/company", handler.GinVerifyStudent)
-	{
-		companies.GET("/all", handler.GetRoleCheckHandlerForStudent(constants.ROLE_COMPANY_ALL_READ), handler.GetAllCompanies)
+	// Session tokens are only minted when a signing key is configured
+	if handler.SessionIssuer == nil {
+		fmt.Println("Session tokens disabled:", constants.SESSION_SIGNING_KEY, "is not set")
 	}
 
-	register := r.Group("/api/register")
-	{
-		register.POST("/recruiterAndCompany", handler.CreateRecruiterAndCompany)
-	}
+	// Temporary group memberships are removed once they expire
+	handler.StartMembershipSweeper()
 
-	logs := r.Group("/api/logs", handler.GinVerifyStudent)
-	{
-		logs.GET("", handler.GetRoleCheckHandlerForStudent(constants.ROLE_ADMIN), handler.GetActivityLogs)
-		logs.POST("", handler.GetRoleCheckHandlerForStudent(constants.ROLE_ADMIN), handler.CreateActivityLog)
+	// Which principal and roles every route needs comes from the route policy file
+	if err := routes.RegisterRoutes(r, handler); err != nil {
+		log.Fatalln("Unable to register routes:", err)
 	}
 
 	port := "" + os.Getenv("PORT")
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"github.com/FrosTiK-SD/auth/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// A key for cron jobs and internal services. Only the hash of the secret is stored
+type APIKey struct {
+	Id         primitive.ObjectID  `json:"_id" bson:"_id"`
+	Name       string              `json:"name" bson:"name"`
+	Owner      primitive.ObjectID  `json:"owner" bson:"owner"`
+	KeyHash    string              `json:"-" bson:"keyHash"`
+	Roles      []string            `json:"roles" bson:"roles"`
+	ExpiresAt  *primitive.DateTime `json:"expiresAt" bson:"expiresAt"`
+	LastUsedAt *primitive.DateTime `json:"lastUsedAt" bson:"lastUsedAt"`
+	Revoked    bool                `json:"revoked" bson:"revoked"`
+	CreatedAt  primitive.DateTime  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt  primitive.DateTime  `json:"updatedAt" bson:"updatedAt"`
+
+	roleSet util.RoleSet
+}
+
+func (apiKey *APIKey) PrincipalID() primitive.ObjectID {
+	return apiKey.Id
+}
+
+func (apiKey *APIKey) PrincipalKind() PrincipalKind {
+	return PRINCIPAL_API_CLIENT
+}
+
+// API clients have no email of their own
+func (apiKey *APIKey) PrincipalEmail() string {
+	return ""
+}
+
+func (apiKey *APIKey) ResolveRoleSet() {
+	apiKey.roleSet = util.NewRoleSetFromRoles(apiKey.Roles)
+}
+
+func (apiKey *APIKey) SetResolvedRoles(roles []string) {
+	apiKey.roleSet = util.NewResolvedRoleSet(roles)
+}
+
+func (apiKey *APIKey) RoleSet() util.RoleSet {
+	return apiKey.roleSet
+}
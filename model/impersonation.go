@@ -0,0 +1,15 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Lets Actor act as Target until ExpiresAt or until it is stopped
+type Impersonation struct {
+	Id        primitive.ObjectID  `json:"_id" bson:"_id"`
+	Actor     primitive.ObjectID  `json:"actor" bson:"actor"`
+	Target    primitive.ObjectID  `json:"target" bson:"target"`
+	Reason    string              `json:"reason" bson:"reason"`
+	ReadOnly  bool                `json:"readOnly" bson:"readOnly"`
+	ExpiresAt primitive.DateTime  `json:"expiresAt" bson:"expiresAt"`
+	EndedAt   *primitive.DateTime `json:"endedAt" bson:"endedAt"`
+	CreatedAt primitive.DateTime  `json:"createdAt" bson:"createdAt"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Bounds the time a student holds a group for. Groups without a membership are held until removed
+type Membership struct {
+	Id         primitive.ObjectID  `json:"_id" bson:"_id"`
+	Student    primitive.ObjectID  `json:"student" bson:"student"`
+	Group      primitive.ObjectID  `json:"group" bson:"group"`
+	ValidFrom  *primitive.DateTime `json:"validFrom" bson:"validFrom"`
+	ValidUntil *primitive.DateTime `json:"validUntil" bson:"validUntil"`
+	CreatedAt  primitive.DateTime  `json:"createdAt" bson:"createdAt"`
+}
+
+func (membership *Membership) IsActive(now time.Time) bool {
+	if membership.ValidFrom != nil && now.Before(membership.ValidFrom.Time()) {
+		return false
+	}
+	if membership.ValidUntil != nil && !now.Before(membership.ValidUntil.Time()) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"github.com/FrosTiK-SD/models/company"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PendingChangeKind string
+
+const (
+	PENDING_CHANGE_GROUP_ASSIGN PendingChangeKind = "group_assign"
+	PENDING_CHANGE_GROUP_EDIT   PendingChangeKind = "group_edit"
+	PENDING_CHANGE_GROUP_SCOPE  PendingChangeKind = "group_scope"
+	PENDING_CHANGE_GROUP_CREATE PendingChangeKind = "group_create"
+)
+
+type PendingChangeStatus string
+
+const (
+	PENDING_CHANGE_PENDING  PendingChangeStatus = "pending"
+	PENDING_CHANGE_APPROVED PendingChangeStatus = "approved"
+	PENDING_CHANGE_REJECTED PendingChangeStatus = "rejected"
+	PENDING_CHANGE_EXPIRED  PendingChangeStatus = "expired"
+)
+
+// A change to a privileged group that only applies once a second admin approves it.
+// Group assignments fill students, role edits fill roles, scope changes fill scope (nil makes the groups institute wide)
+// and group creations fill newGroups
+type PendingChange struct {
+	Id          primitive.ObjectID   `json:"_id" bson:"_id"`
+	Kind        PendingChangeKind    `json:"kind" bson:"kind"`
+	Status      PendingChangeStatus  `json:"status" bson:"status"`
+	Groups      []primitive.ObjectID `json:"groups" bson:"groups"`
+	Students    []primitive.ObjectID `json:"students,omitempty" bson:"students,omitempty"`
+	Roles       []string             `json:"roles,omitempty" bson:"roles,omitempty"`
+	Scope       *Scope               `json:"scope,omitempty" bson:"scope,omitempty"`
+	NewGroups   []company.Group      `json:"newGroups,omitempty" bson:"newGroups,omitempty"`
+	ValidFrom   *primitive.DateTime  `json:"validFrom,omitempty" bson:"validFrom,omitempty"`
+	ValidUntil  *primitive.DateTime  `json:"validUntil,omitempty" bson:"validUntil,omitempty"`
+	RequestedBy primitive.ObjectID   `json:"requestedBy" bson:"requestedBy"`
+	ReviewedBy  *primitive.ObjectID  `json:"reviewedBy" bson:"reviewedBy"`
+	ReviewedAt  *primitive.DateTime  `json:"reviewedAt" bson:"reviewedAt"`
+	ExpiresAt   primitive.DateTime   `json:"expiresAt" bson:"expiresAt"`
+	CreatedAt   primitive.DateTime   `json:"createdAt" bson:"createdAt"`
+}
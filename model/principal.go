@@ -0,0 +1,73 @@
+package model
+
+import (
+	"github.com/FrosTiK-SD/auth/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PrincipalKind string
+
+const (
+	PRINCIPAL_STUDENT    PrincipalKind = "student"
+	PRINCIPAL_RECRUITER  PrincipalKind = "recruiter"
+	PRINCIPAL_API_CLIENT PrincipalKind = "api_client"
+)
+
+// Whoever a request is made by. Middlewares put one in the session and role checks only look at this
+type Principal interface {
+	PrincipalID() primitive.ObjectID
+	PrincipalKind() PrincipalKind
+	PrincipalEmail() string
+	RoleSet() util.RoleSet
+}
+
+func (student *StudentPopulated) PrincipalID() primitive.ObjectID {
+	return student.Id
+}
+
+func (student *StudentPopulated) PrincipalKind() PrincipalKind {
+	return PRINCIPAL_STUDENT
+}
+
+func (student *StudentPopulated) PrincipalEmail() string {
+	return student.InstituteEmail
+}
+
+// Loaders call this once the groups are final, that is after memberships are applied
+func (student *StudentPopulated) ResolveRoleSet() {
+	student.roleSet = util.NewRoleSet(&student.GroupDetails)
+}
+
+// For roles resolved somewhere else, such as the roles claim of a session token
+func (student *StudentPopulated) SetResolvedRoles(roles []string) {
+	student.roleSet = util.NewResolvedRoleSet(roles)
+}
+
+// Empty until the role set is resolved
+func (student *StudentPopulated) RoleSet() util.RoleSet {
+	return student.roleSet
+}
+
+func (recruiter *RecruiterModelPopulated) PrincipalID() primitive.ObjectID {
+	return recruiter.ID
+}
+
+func (recruiter *RecruiterModelPopulated) PrincipalKind() PrincipalKind {
+	return PRINCIPAL_RECRUITER
+}
+
+func (recruiter *RecruiterModelPopulated) PrincipalEmail() string {
+	return recruiter.Email
+}
+
+func (recruiter *RecruiterModelPopulated) ResolveRoleSet() {
+	recruiter.roleSet = util.NewRoleSet(&recruiter.GroupDetails)
+}
+
+func (recruiter *RecruiterModelPopulated) SetResolvedRoles(roles []string) {
+	recruiter.roleSet = util.NewResolvedRoleSet(roles)
+}
+
+func (recruiter *RecruiterModelPopulated) RoleSet() util.RoleSet {
+	return recruiter.roleSet
+}
@@ -1,10 +1,13 @@
 package model
 
 import (
+	"github.com/FrosTiK-SD/auth/util"
 	company "github.com/FrosTiK-SD/models/company"
 )
 
 type RecruiterModelPopulated struct {
 	company.Recruiter
 	GroupDetails []company.Group `json:"groups" bson:"groups"`
+
+	roleSet util.RoleSet
 }
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+type RevocationType string
+
+const (
+	REVOCATION_TOKEN     RevocationType = "token"
+	REVOCATION_USER      RevocationType = "user"
+	REVOCATION_AUTH_TIME RevocationType = "auth_time"
+	REVOCATION_GROUP     RevocationType = "group"
+)
+
+// A token, a user or a single sign-in that may no longer be used. Group revocations only cut off
+// the session tokens of the student that have the group baked in
+type Revocation struct {
+	Type      RevocationType `json:"type"`
+	TokenID   string         `json:"tokenId,omitempty"`
+	Email     string         `json:"email,omitempty"`
+	AuthTime  int            `json:"authTime,omitempty"`
+	Student   string         `json:"student,omitempty"`
+	Group     string         `json:"group,omitempty"`
+	Reason    string         `json:"reason"`
+	RevokedBy string         `json:"revokedBy"`
+	RevokedAt time.Time      `json:"revokedAt"`
+	ExpiresAt time.Time      `json:"expiresAt"`
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/FrosTiK-SD/models/constant"
+	studentModel "github.com/FrosTiK-SD/models/student"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Limits the roles of a group to the students it matches, an empty list matches every value
+type Scope struct {
+	Batches     []studentModel.Batch `json:"batches,omitempty" bson:"batches,omitempty"`
+	Departments []string             `json:"departments,omitempty" bson:"departments,omitempty"`
+	Courses     []constant.Course    `json:"courses,omitempty" bson:"courses,omitempty"`
+
+	// Match the batch or department of whoever holds the group, as for department TPRs
+	OwnBatch      bool `json:"ownBatch,omitempty" bson:"ownBatch,omitempty"`
+	OwnDepartment bool `json:"ownDepartment,omitempty" bson:"ownDepartment,omitempty"`
+}
+
+// The scope stored alongside a group document, groups without one are institute wide
+type GroupScope struct {
+	Id    primitive.ObjectID `json:"_id" bson:"_id"`
+	Name  string             `json:"name" bson:"name"`
+	Roles []string           `json:"roles" bson:"roles"`
+	Scope *Scope             `json:"scope" bson:"scope"`
+}
+
+func sameBatch(a *studentModel.Batch, b *studentModel.Batch) bool {
+	return a != nil && b != nil && a.StartYear == b.StartYear && a.EndYear == b.EndYear
+}
+
+// Departments are stored lower cased, as the student search expects, whatever case a scope was written in
+func normalizeDepartment(department string) string {
+	return strings.ToLower(strings.TrimSpace(department))
+}
+
+func sameDepartment(a string, b string) bool {
+	return normalizeDepartment(a) != "" && normalizeDepartment(a) == normalizeDepartment(b)
+}
+
+// The same conditions as Matches as a filter on the students collection, for listings
+func (scope *Scope) Filter(actor *studentModel.Student) bson.M {
+	if scope == nil {
+		return bson.M{}
+	}
+
+	conditions := []bson.M{}
+	if len(scope.Batches) != 0 {
+		batches := make([]bson.M, 0, len(scope.Batches))
+		for _, batch := range scope.Batches {
+			batches = append(batches, bson.M{"batch.startYear": batch.StartYear, "batch.endYear": batch.EndYear})
+		}
+		conditions = append(conditions, bson.M{"$or": batches})
+	}
+	if len(scope.Departments) != 0 {
+		departments := make([]string, 0, len(scope.Departments))
+		for _, department := range scope.Departments {
+			departments = append(departments, normalizeDepartment(department))
+		}
+		conditions = append(conditions, bson.M{"department": bson.M{"$in": departments}})
+	}
+	if len(scope.Courses) != 0 {
+		conditions = append(conditions, bson.M{"course": bson.M{"$in": scope.Courses}})
+	}
+
+	// Nothing matches the own batch or department of an actor without one
+	if scope.OwnBatch {
+		if actor.Batch == nil {
+			return matchNothing()
+		}
+		conditions = append(conditions, bson.M{"batch.startYear": actor.Batch.StartYear, "batch.endYear": actor.Batch.EndYear})
+	}
+	if scope.OwnDepartment {
+		if normalizeDepartment(actor.Department) == "" {
+			return matchNothing()
+		}
+		conditions = append(conditions, bson.M{"department": normalizeDepartment(actor.Department)})
+	}
+
+	if len(conditions) == 0 {
+		return bson.M{}
+	}
+	return bson.M{"$and": conditions}
+}
+
+func matchNothing() bson.M {
+	return bson.M{"_id": bson.M{"$in": []primitive.ObjectID{}}}
+}
+
+func (scope *Scope) Matches(actor *studentModel.Student, target *studentModel.Student) bool {
+	if scope == nil {
+		return true
+	}
+
+	if len(scope.Batches) != 0 {
+		found := false
+		for idx := range scope.Batches {
+			if sameBatch(&scope.Batches[idx], target.Batch) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(scope.Departments) != 0 {
+		found := false
+		for _, department := range scope.Departments {
+			if sameDepartment(department, target.Department) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(scope.Courses) != 0 {
+		found := false
+		for _, course := range scope.Courses {
+			if target.Course != nil && course == *target.Course {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if scope.OwnBatch && !sameBatch(actor.Batch, target.Batch) {
+		return false
+	}
+	if scope.OwnDepartment && !sameDepartment(actor.Department, target.Department) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/FrosTiK-SD/models/constant"
+	studentModel "github.com/FrosTiK-SD/models/student"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var batch2020 = studentModel.Batch{StartYear: 2020, EndYear: 2024}
+var batch2021 = studentModel.Batch{StartYear: 2021, EndYear: 2025}
+
+func testScopeStudent(batch *studentModel.Batch, department string, course string) *studentModel.Student {
+	student := &studentModel.Student{Batch: batch, Department: department}
+	if course != "" {
+		studentCourse := constant.Course(course)
+		student.Course = &studentCourse
+	}
+	return student
+}
+
+func TestScopeMatches(t *testing.T) {
+	actor := testScopeStudent(&batch2020, "cse", "BTECH")
+
+	tests := []struct {
+		name   string
+		scope  *Scope
+		actor  *studentModel.Student
+		target *studentModel.Student
+		want   bool
+	}{
+		{"no scope", nil, actor, testScopeStudent(&batch2021, "ece", "IDD"), true},
+		{"empty scope", &Scope{}, actor, testScopeStudent(&batch2021, "ece", "IDD"), true},
+		{"batch", &Scope{Batches: []studentModel.Batch{batch2020}}, actor, testScopeStudent(&batch2020, "ece", "IDD"), true},
+		{"another batch", &Scope{Batches: []studentModel.Batch{batch2020}}, actor, testScopeStudent(&batch2021, "cse", "BTECH"), false},
+		{"target without a batch", &Scope{Batches: []studentModel.Batch{batch2020}}, actor, testScopeStudent(nil, "cse", "BTECH"), false},
+		{"department", &Scope{Departments: []string{"mec", "cse"}}, actor, testScopeStudent(&batch2021, "cse", "IDD"), true},
+		{"department in another case", &Scope{Departments: []string{"CSE"}}, actor, testScopeStudent(&batch2021, "cse", "IDD"), true},
+		{"another department", &Scope{Departments: []string{"cse"}}, actor, testScopeStudent(&batch2020, "ece", "BTECH"), false},
+		{"course", &Scope{Courses: []constant.Course{"IDD"}}, actor, testScopeStudent(&batch2021, "ece", "IDD"), true},
+		{"target without a course", &Scope{Courses: []constant.Course{"IDD"}}, actor, testScopeStudent(&batch2021, "ece", ""), false},
+		{"every list must match", &Scope{Batches: []studentModel.Batch{batch2020}, Departments: []string{"cse"}}, actor, testScopeStudent(&batch2020, "ece", "BTECH"), false},
+		{"own batch", &Scope{OwnBatch: true}, actor, testScopeStudent(&batch2020, "ece", "IDD"), true},
+		{"not the own batch", &Scope{OwnBatch: true}, actor, testScopeStudent(&batch2021, "cse", "BTECH"), false},
+		{"own batch of an actor without one", &Scope{OwnBatch: true}, testScopeStudent(nil, "cse", ""), testScopeStudent(nil, "cse", ""), false},
+		{"own department", &Scope{OwnDepartment: true}, actor, testScopeStudent(&batch2021, "cse", "IDD"), true},
+		{"own department in another case", &Scope{OwnDepartment: true}, testScopeStudent(&batch2020, "CSE", ""), testScopeStudent(&batch2021, "cse", "IDD"), true},
+		{"not the own department", &Scope{OwnDepartment: true}, actor, testScopeStudent(&batch2020, "ece", "BTECH"), false},
+		{"own department of an actor without one", &Scope{OwnDepartment: true}, testScopeStudent(&batch2020, "", ""), testScopeStudent(&batch2020, "", ""), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.scope.Matches(test.actor, test.target); got != test.want {
+				t.Errorf("Matches() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScopeFilter(t *testing.T) {
+	actor := testScopeStudent(&batch2020, "CSE", "BTECH")
+
+	tests := []struct {
+		name  string
+		scope *Scope
+		actor *studentModel.Student
+		want  bson.M
+	}{
+		{"no scope", nil, actor, bson.M{}},
+		{"empty scope", &Scope{}, actor, bson.M{}},
+		{"departments are lower cased", &Scope{Departments: []string{"CSE", " mec "}}, actor, bson.M{"$and": []bson.M{
+			{"department": bson.M{"$in": []string{"cse", "mec"}}},
+		}}},
+		{"batches", &Scope{Batches: []studentModel.Batch{*actor.Batch}}, actor, bson.M{"$and": []bson.M{
+			{"$or": []bson.M{{"batch.startYear": actor.Batch.StartYear, "batch.endYear": actor.Batch.EndYear}}},
+		}}},
+		{"courses", &Scope{Courses: []constant.Course{"IDD"}}, actor, bson.M{"$and": []bson.M{
+			{"course": bson.M{"$in": []constant.Course{"IDD"}}},
+		}}},
+		{"own batch and department", &Scope{OwnBatch: true, OwnDepartment: true}, actor, bson.M{"$and": []bson.M{
+			{"batch.startYear": actor.Batch.StartYear, "batch.endYear": actor.Batch.EndYear},
+			{"department": "cse"},
+		}}},
+		{"own batch of an actor without one", &Scope{OwnBatch: true}, testScopeStudent(nil, "cse", ""), matchNothing()},
+		{"own department of an actor without one", &Scope{OwnDepartment: true}, testScopeStudent(&batch2020, "", ""), matchNothing()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.scope.Filter(test.actor); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Filter() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
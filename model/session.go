@@ -0,0 +1,16 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// A refresh token handed out with a session token. Rotated tokens share a family
+type RefreshSession struct {
+	Id        primitive.ObjectID  `json:"_id" bson:"_id"`
+	Family    primitive.ObjectID  `json:"family" bson:"family"`
+	Student   primitive.ObjectID  `json:"student" bson:"student"`
+	TokenHash string              `json:"tokenHash" bson:"tokenHash"`
+	AuthTime  int                 `json:"authTime" bson:"authTime"`
+	ExpiresAt primitive.DateTime  `json:"expiresAt" bson:"expiresAt"`
+	RotatedAt *primitive.DateTime `json:"rotatedAt" bson:"rotatedAt"`
+	Revoked   bool                `json:"revoked" bson:"revoked"`
+	CreatedAt primitive.DateTime  `json:"createdAt" bson:"createdAt"`
+}
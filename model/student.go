@@ -1,6 +1,7 @@
 package model
 
 import (
+	"github.com/FrosTiK-SD/auth/util"
 	group "github.com/FrosTiK-SD/models/company"
 	studentModel "github.com/FrosTiK-SD/models/student"
 )
@@ -8,4 +9,6 @@ import (
 type StudentPopulated struct {
 	studentModel.Student
 	GroupDetails []group.Group `json:"groups" bson:"groups"`
+
+	roleSet util.RoleSet
 }
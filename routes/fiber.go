@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/FrosTiK-SD/auth/handler"
+	"github.com/gofiber/fiber/v2"
+)
+
+// The Fiber counterpart of RegisterRoutes. Only the token verification routes have Fiber handlers,
+// the other groups are skipped. They are public in the route policy, so no policy is enforced here
+func RegisterFiberRoutes(r fiber.Router, h *handler.Handler, opts ...Option) {
+	options := getOptions(opts)
+
+	api := r.Group(options.prefix, options.fiberMiddleware...)
+
+	if options.groups[TOKEN] {
+		token := api.Group("/api/token")
+		{
+			token.Get("/verify", h.FiberHandlerVerifyRecruiterIdToken)
+			token.Get("/student/verify", h.FiberHandlerVerifyStudentIdToken)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package routes
+
+import (
+	"strings"
+
+	"github.com/FrosTiK-SD/auth/controller"
+	"github.com/FrosTiK-SD/auth/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// Mounts the API on r, every route group unless WithGroups is given.
+// Which principal and roles every route needs comes from the route policy file, which has to load
+func RegisterRoutes(r gin.IRouter, h *handler.Handler, opts ...Option) error {
+	options := getOptions(opts)
+
+	api := r.Group(options.prefix)
+	if options.enforcePolicy {
+		if err := controller.StartRoutePolicyRefresh(); err != nil {
+			return err
+		}
+		api.Use(h.GetPolicyEnforcer(strings.TrimRight(api.BasePath(), "/")))
+	}
+	api.Use(options.middleware...)
+
+	if options.groups[TOKEN] {
+		token := api.Group("/api/token")
+		{
+			token.GET("/verify", h.HandlerVerifyRecruiterIdToken)
+			token.GET("/student/verify", h.HandlerVerifyStudentIdToken)
+			token.GET("/invalidate_cache", h.InvalidateCache)
+			token.POST("/exchange", h.HandlerExchangeToken)
+			token.POST("/refresh", h.HandlerRefreshToken)
+			token.GET("/jwks", h.HandlerGetSessionJWKS)
+			token.POST("/introspect", h.HandlerIntrospectToken)
+		}
+	}
+
+	if options.groups[STUDENT] {
+		student := api.Group("/api/student")
+		{
+			student.GET("", h.GetAllStudents)
+			student.GET("/id", h.GetStudentById)
+			student.GET("/tpr/all", h.GetAllTprs)
+			student.GET("/tprLogin", h.HandlerTprLogin)
+			student.PUT("/update", h.HandlerUpdateStudentDetails)
+
+			student.GET("/profile", h.HandlerGetStudentProfile)
+			student.PUT("/profile", h.HandlerUpdateStudentProfile)
+			student.GET("/profile/id", h.HandlerGetStudentProfileById)
+			student.PUT("/profile/verify", h.HandlerVerifyStudentProfile)
+			student.POST("/register", h.HandlerRegisterStudentDetails)
+			student.GET("/admin/profile/id", h.HandlerGetStudentProfileById)
+			student.PUT("/admin/update", h.HandlerAdminUpdateStudentDetails)
+			student.PUT("/admin/status", h.HandlerAdminUpdateStudentPlacementStatus)
+			student.GET("/admin/export/csv", h.HandlerAdminExportStudentsCSV)
+			student.GET("/admin/csv", h.HandlerAdminExportStudentsCSV)
+			student.PUT("/admin/unverify-batch", h.HandlerUnverifyStudentProfilesByBatch)
+		}
+	}
+
+	if options.groups[GROUP] {
+		group := api.Group("/api/group")
+		{
+			group.GET("", h.GetAllGroups)
+			group.POST("/batch", h.BatchCreateGroup)
+			group.PUT("/batch/edit", h.BatchEditGroup)
+			group.PUT("/scope", h.SetGroupScope)
+			group.DELETE("/batch/delete", h.BatchDeleteGroup)
+			group.POST("/batch/assign", h.BatchAssignGroup)
+		}
+	}
+
+	if options.groups[DOMAIN] {
+		domain := api.Group("/api/domain")
+		{
+			domain.GET("", h.GetAllDomains)
+			domain.GET("/id", h.GetDomainById)
+			domain.POST("/batch", h.BatchCreateDomain)
+			domain.PUT("/id", h.EditDomainById)
+			domain.DELETE("/id", h.DeleteDomainById)
+		}
+	}
+
+	if options.groups[COMPANY] {
+		companies := api.Group("/api/company")
+		{
+			companies.GET("/all", h.GetAllCompanies)
+		}
+	}
+
+	if options.groups[REGISTER] {
+		register := api.Group("/api/register")
+		{
+			register.POST("/recruiterAndCompany", h.CreateRecruiterAndCompany)
+		}
+	}
+
+	if options.groups[REVOCATION] {
+		revocation := api.Group("/api/revocation")
+		{
+			revocation.GET("", h.HandlerListRevocations)
+			revocation.POST("", h.HandlerRevoke)
+		}
+	}
+
+	if options.groups[IMPERSONATION] {
+		impersonation := api.Group("/api/impersonation")
+		{
+			impersonation.GET("", h.GetActiveImpersonation)
+			impersonation.POST("/start", h.StartImpersonation)
+			impersonation.POST("/stop", h.StopImpersonation)
+		}
+	}
+
+	if options.groups[APPROVAL] {
+		approval := api.Group("/api/approval")
+		{
+			approval.GET("", h.GetPendingChanges)
+			approval.POST("/approve", h.ApprovePendingChange)
+			approval.POST("/reject", h.RejectPendingChange)
+		}
+	}
+
+	if options.groups[API_KEY] {
+		apiKey := api.Group("/api/apikey")
+		{
+			apiKey.GET("", h.GetAllAPIKeys)
+			apiKey.POST("", h.CreateAPIKey)
+			apiKey.PUT("/rotate", h.RotateAPIKey)
+			apiKey.DELETE("", h.RevokeAPIKey)
+		}
+	}
+
+	if options.groups[POLICY] {
+		api.GET("/api/policy", h.HandlerGetRoutePolicy)
+		api.POST("/api/policy/explain", h.HandlerExplainAccess)
+	}
+	if options.groups[ROLES] {
+		api.GET("/api/roles", h.GetRoleCatalog)
+	}
+	if options.groups[PERMISSIONS] {
+		api.GET("/api/me/permissions", h.GetMyPermissions)
+	}
+
+	if options.groups[LOGS] {
+		logs := api.Group("/api/logs")
+		{
+			logs.GET("", h.GetActivityLogs)
+			logs.POST("", h.CreateActivityLog)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+)
+
+// A set of routes that is mounted together, named after its path under /api
+type RouteGroup string
+
+const (
+	TOKEN         RouteGroup = "token"
+	STUDENT       RouteGroup = "student"
+	GROUP         RouteGroup = "group"
+	DOMAIN        RouteGroup = "domain"
+	COMPANY       RouteGroup = "company"
+	REGISTER      RouteGroup = "register"
+	REVOCATION    RouteGroup = "revocation"
+	IMPERSONATION RouteGroup = "impersonation"
+	APPROVAL      RouteGroup = "approval"
+	API_KEY       RouteGroup = "apikey"
+	POLICY        RouteGroup = "policy"
+	ROLES         RouteGroup = "roles"
+	PERMISSIONS   RouteGroup = "me"
+	LOGS          RouteGroup = "logs"
+)
+
+var ALL_ROUTE_GROUPS = []RouteGroup{TOKEN, STUDENT, GROUP, DOMAIN, COMPANY, REGISTER, REVOCATION, IMPERSONATION, APPROVAL, API_KEY, POLICY, ROLES, PERMISSIONS, LOGS}
+
+type options struct {
+	groups          map[RouteGroup]bool
+	prefix          string
+	middleware      []gin.HandlerFunc
+	fiberMiddleware []fiber.Handler
+	enforcePolicy   bool
+}
+
+type Option func(*options)
+
+func getOptions(opts []Option) *options {
+	options := &options{
+		groups:        map[RouteGroup]bool{},
+		enforcePolicy: true,
+	}
+	for _, group := range ALL_ROUTE_GROUPS {
+		options.groups[group] = true
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// Mounts only the given groups instead of all of them
+func WithGroups(groups ...RouteGroup) Option {
+	return func(options *options) {
+		options.groups = map[RouteGroup]bool{}
+		for _, group := range groups {
+			options.groups[group] = true
+		}
+	}
+}
+
+// Mounts the routes under prefix, the route policy still matches them without it
+func WithPrefix(prefix string) Option {
+	return func(options *options) {
+		options.prefix = prefix
+	}
+}
+
+// Runs after the route policy, so the session is already in the context
+func WithMiddleware(middleware ...gin.HandlerFunc) Option {
+	return func(options *options) {
+		options.middleware = append(options.middleware, middleware...)
+	}
+}
+
+// Fiber groups install their middleware on every route under the prefix, not only on the ones mounted here
+func WithFiberMiddleware(middleware ...fiber.Handler) Option {
+	return func(options *options) {
+		options.fiberMiddleware = append(options.fiberMiddleware, middleware...)
+	}
+}
+
+// For engines that install handler.GinEnforcePolicy themselves
+func WithoutPolicy() Option {
+	return func(options *options) {
+		options.enforcePolicy = false
+	}
+}
@@ -1,6 +1,8 @@
 package util
 
 import (
+	"strings"
+
 	"github.com/FrosTiK-SD/auth/constants"
 	"github.com/gin-gonic/gin"
 )
@@ -8,3 +10,13 @@ import (
 func GetNoCache(ctx *gin.Context) bool {
 	return ctx.GetHeader(constants.CACHE_CONTROL_HEADER) == constants.NO_CACHE
 }
+
+// Reads the key out of an "Authorization: ApiKey <key>" header
+func GetAPIKey(authorization string) (string, bool) {
+	scheme, key, found := strings.Cut(strings.TrimSpace(authorization), " ")
+	if !found || !strings.EqualFold(scheme, constants.API_KEY_AUTHORIZATION_SCHEME) {
+		return "", false
+	}
+	key = strings.TrimSpace(key)
+	return key, key != ""
+}
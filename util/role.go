@@ -1,16 +1,56 @@
 package util
 
 import (
+	"sort"
+
 	student "github.com/FrosTiK-SD/models/company"
 )
 
+// Implied roles count, so an ADMIN passes every check
 func CheckRoleExists(groups *[]student.Group, role string) bool {
-	checkRoleStatus := false
+	return NewRoleSet(groups).Has(role)
+}
+
+// Roles of a principal expanded through the role graph, computed once so that checks are map lookups
+type RoleSet map[string]struct{}
+
+func NewRoleSet(groups *[]student.Group) RoleSet {
+	roleSet := RoleSet{}
 	for _, group := range *groups {
-		if ArrayContains(group.Roles, role) {
-			checkRoleStatus = true
-			break
+		for _, role := range group.Roles {
+			roleSet[role] = struct{}{}
 		}
 	}
-	return checkRoleStatus
+	return GetRoleGraph().Expand(roleSet)
+}
+
+func NewRoleSetFromRoles(roles []string) RoleSet {
+	roleSet := RoleSet{}
+	for _, role := range roles {
+		roleSet[role] = struct{}{}
+	}
+	return GetRoleGraph().Expand(roleSet)
+}
+
+// For roles that were expanded already, such as those sent back by the auth service
+func NewResolvedRoleSet(roles []string) RoleSet {
+	roleSet := RoleSet{}
+	for _, role := range roles {
+		roleSet[role] = struct{}{}
+	}
+	return roleSet
+}
+
+func (roleSet RoleSet) Has(role string) bool {
+	_, found := roleSet[role]
+	return found
+}
+
+func (roleSet RoleSet) List() []string {
+	roles := make([]string, 0, len(roleSet))
+	for role := range roleSet {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
 }
@@ -0,0 +1,110 @@
+package util
+
+import (
+	"strings"
+)
+
+// A condition on the roles of a principal. Evaluate returns the clause that was not met on failure
+type RoleExpression interface {
+	Evaluate(roleSet RoleSet) (bool, string)
+	String() string
+}
+
+type roleClause struct {
+	role string
+}
+
+type allOfClause struct {
+	expressions []RoleExpression
+}
+
+type anyOfClause struct {
+	expressions []RoleExpression
+}
+
+type notClause struct {
+	expression RoleExpression
+}
+
+func Role(role string) RoleExpression {
+	return &roleClause{role: role}
+}
+
+// Shorthand for AllOf over plain roles
+func Roles(roles ...string) RoleExpression {
+	expressions := make([]RoleExpression, 0, len(roles))
+	for _, role := range roles {
+		expressions = append(expressions, Role(role))
+	}
+	return AllOf(expressions...)
+}
+
+func AllOf(expressions ...RoleExpression) RoleExpression {
+	return &allOfClause{expressions: expressions}
+}
+
+func AnyOf(expressions ...RoleExpression) RoleExpression {
+	return &anyOfClause{expressions: expressions}
+}
+
+func Not(expression RoleExpression) RoleExpression {
+	return &notClause{expression: expression}
+}
+
+func (clause *roleClause) Evaluate(roleSet RoleSet) (bool, string) {
+	if roleSet.Has(clause.role) {
+		return true, ""
+	}
+	return false, clause.String()
+}
+
+func (clause *roleClause) String() string {
+	return clause.role
+}
+
+// The first unmet child is reported
+func (clause *allOfClause) Evaluate(roleSet RoleSet) (bool, string) {
+	for _, expression := range clause.expressions {
+		if ok, unmet := expression.Evaluate(roleSet); !ok {
+			return false, unmet
+		}
+	}
+	return true, ""
+}
+
+func (clause *allOfClause) String() string {
+	return "AllOf(" + joinExpressions(clause.expressions) + ")"
+}
+
+// None of the children were met, so the whole clause is reported
+func (clause *anyOfClause) Evaluate(roleSet RoleSet) (bool, string) {
+	for _, expression := range clause.expressions {
+		if ok, _ := expression.Evaluate(roleSet); ok {
+			return true, ""
+		}
+	}
+	return false, clause.String()
+}
+
+func (clause *anyOfClause) String() string {
+	return "AnyOf(" + joinExpressions(clause.expressions) + ")"
+}
+
+func (clause *notClause) Evaluate(roleSet RoleSet) (bool, string) {
+	if ok, _ := clause.expression.Evaluate(roleSet); ok {
+		return false, clause.String()
+	}
+	return true, ""
+}
+
+func (clause *notClause) String() string {
+	return "Not(" + clause.expression.String() + ")"
+}
+
+func joinExpressions(expressions []RoleExpression) string {
+	clauses := make([]string, 0, len(expressions))
+	for _, expression := range expressions {
+		clauses = append(clauses, expression.String())
+	}
+	return strings.Join(clauses, ", ")
+}
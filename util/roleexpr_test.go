@@ -0,0 +1,46 @@
+package util
+
+import "testing"
+
+func TestRoleExpressionEvaluate(t *testing.T) {
+	roleSet := NewResolvedRoleSet([]string{"TPR", "STUDENT", "GROUP_READ"})
+
+	tests := []struct {
+		name       string
+		expression RoleExpression
+		met        bool
+		unmet      string
+	}{
+		{"role held", Role("TPR"), true, ""},
+		{"role missing", Role("ADMIN"), false, "ADMIN"},
+		{"allOf met", AllOf(Role("TPR"), Role("STUDENT")), true, ""},
+		{"allOf reports the first unmet child", AllOf(Role("TPR"), Role("ADMIN"), Role("GROUP_EDIT")), false, "ADMIN"},
+		{"allOf reports the unmet nested clause", AllOf(Role("TPR"), AnyOf(Role("ADMIN"), Role("GROUP_EDIT"))), false, "AnyOf(ADMIN, GROUP_EDIT)"},
+		{"empty allOf", AllOf(), true, ""},
+		{"anyOf met by one child", AnyOf(Role("ADMIN"), Role("STUDENT")), true, ""},
+		{"anyOf reports the whole clause", AnyOf(Role("ADMIN"), Role("GROUP_EDIT")), false, "AnyOf(ADMIN, GROUP_EDIT)"},
+		{"empty anyOf", AnyOf(), false, "AnyOf()"},
+		{"not of a missing role", Not(Role("ADMIN")), true, ""},
+		{"not of a held role", Not(Role("TPR")), false, "Not(TPR)"},
+		{"not of a nested clause", Not(AllOf(Role("TPR"), Role("STUDENT"))), false, "Not(AllOf(TPR, STUDENT))"},
+		{"roles shorthand", Roles("TPR", "GROUP_READ"), true, ""},
+		{"roles shorthand unmet", Roles("TPR", "GROUP_DELETE"), false, "GROUP_DELETE"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			met, unmet := test.expression.Evaluate(roleSet)
+			if met != test.met || unmet != test.unmet {
+				t.Errorf("Evaluate() = (%v, %q), want (%v, %q)", met, unmet, test.met, test.unmet)
+			}
+		})
+	}
+}
+
+func TestRoleExpressionString(t *testing.T) {
+	expression := AnyOf(Role("ADMIN"), AllOf(Role("TPR"), Not(Role("STUDENT"))))
+	want := "AnyOf(ADMIN, AllOf(TPR, Not(STUDENT)))"
+	if got := expression.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
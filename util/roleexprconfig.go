@@ -0,0 +1,97 @@
+package util
+
+import (
+	"errors"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// JSON form of a RoleExpression. A plain string is a single role, otherwise exactly one field is set,
+// for example {"anyOf": ["ADMIN", {"allOf": ["TPR", "STUDENT_VERIFY"]}]}
+type RoleExpressionConfig struct {
+	Role  string                 `json:"role,omitempty"`
+	AllOf []RoleExpressionConfig `json:"allOf,omitempty"`
+	AnyOf []RoleExpressionConfig `json:"anyOf,omitempty"`
+	Not   *RoleExpressionConfig  `json:"not,omitempty"`
+}
+
+type roleExpressionConfigFields RoleExpressionConfig
+
+func (config *RoleExpressionConfig) UnmarshalJSON(data []byte) error {
+	var role string
+	if err := json.Unmarshal(data, &role); err == nil {
+		*config = RoleExpressionConfig{Role: role}
+		return nil
+	}
+	return json.Unmarshal(data, (*roleExpressionConfigFields)(config))
+}
+
+func (config *RoleExpressionConfig) Build() (RoleExpression, error) {
+	set := 0
+	if config.Role != "" {
+		set++
+	}
+	if config.AllOf != nil {
+		set++
+	}
+	if config.AnyOf != nil {
+		set++
+	}
+	if config.Not != nil {
+		set++
+	}
+	if set != 1 {
+		return nil, errors.New("a role expression needs exactly one of role, allOf, anyOf or not")
+	}
+
+	switch {
+	case config.Role != "":
+		return Role(config.Role), nil
+	case config.Not != nil:
+		expression, err := config.Not.Build()
+		if err != nil {
+			return nil, err
+		}
+		return Not(expression), nil
+	}
+
+	children := config.AllOf
+	if config.AnyOf != nil {
+		children = config.AnyOf
+	}
+	if len(children) == 0 {
+		return nil, errors.New("allOf and anyOf need at least one role expression")
+	}
+	expressions := make([]RoleExpression, 0, len(children))
+	for idx := range children {
+		expression, err := children[idx].Build()
+		if err != nil {
+			return nil, err
+		}
+		expressions = append(expressions, expression)
+	}
+
+	if config.AnyOf != nil {
+		return AnyOf(expressions...), nil
+	}
+	return AllOf(expressions...), nil
+}
+
+// Every role the expression mentions, negated or not
+func (config *RoleExpressionConfig) RoleNames() []string {
+	var roles []string
+	if config.Role != "" {
+		roles = append(roles, config.Role)
+	}
+	for _, children := range [][]RoleExpressionConfig{config.AllOf, config.AnyOf} {
+		for idx := range children {
+			roles = append(roles, children[idx].RoleNames()...)
+		}
+	}
+	if config.Not != nil {
+		roles = append(roles, config.Not.RoleNames()...)
+	}
+	return roles
+}
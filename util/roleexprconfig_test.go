@@ -0,0 +1,105 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRoleExpressionConfigBuild(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"plain string", `"ADMIN"`, "ADMIN"},
+		{"role field", `{"role": "TPR"}`, "TPR"},
+		{"allOf", `{"allOf": ["TPR", "STUDENT_VERIFY"]}`, "AllOf(TPR, STUDENT_VERIFY)"},
+		{"anyOf", `{"anyOf": ["ADMIN", {"allOf": ["TPR", "STUDENT_VERIFY"]}]}`, "AnyOf(ADMIN, AllOf(TPR, STUDENT_VERIFY))"},
+		{"not", `{"not": {"role": "recruiter"}}`, "Not(recruiter)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := RoleExpressionConfig{}
+			if err := json.Unmarshal([]byte(test.config), &config); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			expression, err := config.Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if got := expression.String(); got != test.want {
+				t.Errorf("Build() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRoleExpressionConfigBuildErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"nothing set", `{}`},
+		{"empty role", `""`},
+		{"two fields set", `{"role": "ADMIN", "not": "TPR"}`},
+		{"empty allOf", `{"allOf": []}`},
+		{"empty anyOf", `{"anyOf": []}`},
+		{"invalid child", `{"anyOf": ["ADMIN", {}]}`},
+		{"invalid negation", `{"not": {"allOf": []}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := RoleExpressionConfig{}
+			if err := json.Unmarshal([]byte(test.config), &config); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if expression, err := config.Build(); err == nil {
+				t.Errorf("Build() = %q, want an error", expression.String())
+			}
+		})
+	}
+}
+
+func TestRoleExpressionConfigEvaluate(t *testing.T) {
+	config := RoleExpressionConfig{}
+	if err := json.Unmarshal([]byte(`{"allOf": ["TPR", {"not": "ADMIN"}]}`), &config); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	expression, err := config.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		roles []string
+		met   bool
+		unmet string
+	}{
+		{"met", []string{"TPR"}, true, ""},
+		{"required role missing", []string{"STUDENT"}, false, "TPR"},
+		{"negated role held", []string{"TPR", "ADMIN"}, false, "Not(ADMIN)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			met, unmet := expression.Evaluate(NewResolvedRoleSet(test.roles))
+			if met != test.met || unmet != test.unmet {
+				t.Errorf("Evaluate() = (%v, %q), want (%v, %q)", met, unmet, test.met, test.unmet)
+			}
+		})
+	}
+}
+
+func TestRoleExpressionConfigRoleNames(t *testing.T) {
+	config := RoleExpressionConfig{}
+	if err := json.Unmarshal([]byte(`{"anyOf": ["ADMIN", {"allOf": ["TPR", {"not": "recruiter"}]}]}`), &config); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := []string{"ADMIN", "TPR", "recruiter"}
+	if got := config.RoleNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("RoleNames() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package util
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"github.com/FrosTiK-SD/auth/constants"
+)
+
+// Which roles each role implies. A role implying "*" implies every known role
+type RoleGraph struct {
+	implies map[string][]string
+	known   []string
+}
+
+var currentRoleGraph atomic.Pointer[RoleGraph]
+
+func NewRoleGraph(implications map[string][]string) *RoleGraph {
+	roleGraph := &RoleGraph{implies: map[string][]string{}}
+
+	known := map[string]struct{}{}
+	for _, role := range constants.ALL_ROLES {
+		known[role] = struct{}{}
+	}
+	for role, impliedRoles := range implications {
+		known[role] = struct{}{}
+		for _, impliedRole := range impliedRoles {
+			if impliedRole != constants.ROLE_WILDCARD {
+				known[impliedRole] = struct{}{}
+			}
+			if !ArrayContains(roleGraph.implies[role], impliedRole) {
+				roleGraph.implies[role] = append(roleGraph.implies[role], impliedRole)
+			}
+		}
+	}
+	for role := range known {
+		roleGraph.known = append(roleGraph.known, role)
+	}
+
+	return roleGraph
+}
+
+// The graph role sets are expanded with, the built-in defaults until one is set
+func GetRoleGraph() *RoleGraph {
+	if roleGraph := currentRoleGraph.Load(); roleGraph != nil {
+		return roleGraph
+	}
+	return NewRoleGraph(constants.DEFAULT_ROLE_IMPLICATIONS)
+}
+
+func SetRoleGraph(roleGraph *RoleGraph) {
+	currentRoleGraph.Store(roleGraph)
+}
+
+// Adds every role that is implied, directly or transitively, by the given ones
+func (roleGraph *RoleGraph) Expand(roleSet RoleSet) RoleSet {
+	pending := make([]string, 0, len(roleSet))
+	for role := range roleSet {
+		pending = append(pending, role)
+	}
+
+	for len(pending) != 0 {
+		role := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		for _, impliedRole := range roleGraph.implies[role] {
+			if impliedRole == constants.ROLE_WILDCARD {
+				for _, knownRole := range roleGraph.known {
+					roleSet[knownRole] = struct{}{}
+				}
+				continue
+			}
+			if !roleSet.Has(impliedRole) {
+				roleSet[impliedRole] = struct{}{}
+				pending = append(pending, impliedRole)
+			}
+		}
+	}
+
+	return roleSet
+}
+
+func (roleGraph *RoleGraph) Implications() map[string][]string {
+	return roleGraph.implies
+}
+
+// Built-in roles and every role named by the configured implications
+func (roleGraph *RoleGraph) Known() []string {
+	known := append([]string{}, roleGraph.known...)
+	sort.Strings(known)
+	return known
+}
+
+func (roleGraph *RoleGraph) IsKnown(role string) bool {
+	return ArrayContains(roleGraph.known, role)
+}
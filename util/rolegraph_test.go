@@ -0,0 +1,98 @@
+package util
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/FrosTiK-SD/auth/constants"
+)
+
+func TestRoleGraphExpand(t *testing.T) {
+	roleGraph := NewRoleGraph(map[string][]string{
+		"PLACEMENT_HEAD":          {"PLACEMENT_COORDINATOR"},
+		"PLACEMENT_COORDINATOR":   {constants.ROLE_TPR, constants.ROLE_GROUP_EDIT},
+		constants.ROLE_GROUP_EDIT: {constants.ROLE_GROUP_READ},
+		"CYCLE_A":                 {"CYCLE_B"},
+		"CYCLE_B":                 {"CYCLE_A"},
+		"SUPERUSER":               {constants.ROLE_ADMIN},
+		"AUDITOR":                 {constants.ROLE_WILDCARD},
+	})
+
+	everyRole := roleGraph.Known()
+
+	tests := []struct {
+		name  string
+		roles []string
+		want  []string
+	}{
+		{"nothing", []string{}, []string{}},
+		{"no implications", []string{constants.ROLE_STUDENT}, []string{constants.ROLE_STUDENT}},
+		{"unknown role is kept", []string{"SOMETHING_ELSE"}, []string{"SOMETHING_ELSE"}},
+		{"direct", []string{constants.ROLE_GROUP_EDIT}, []string{constants.ROLE_GROUP_EDIT, constants.ROLE_GROUP_READ}},
+		{"transitive", []string{"PLACEMENT_HEAD"}, []string{
+			constants.ROLE_GROUP_EDIT, constants.ROLE_GROUP_READ, "PLACEMENT_COORDINATOR", "PLACEMENT_HEAD", constants.ROLE_TPR,
+		}},
+		{"cycle", []string{"CYCLE_A"}, []string{"CYCLE_A", "CYCLE_B"}},
+		{"wildcard", []string{"AUDITOR"}, everyRole},
+		{"implications replace the defaults", []string{"SUPERUSER"}, []string{constants.ROLE_ADMIN, "SUPERUSER"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := roleGraph.Expand(NewResolvedRoleSet(test.roles)).List()
+			want := append([]string{}, test.want...)
+			sort.Strings(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Expand(%v) = %v, want %v", test.roles, got, want)
+			}
+		})
+	}
+}
+
+func TestRoleGraphWildcard(t *testing.T) {
+	roleGraph := NewRoleGraph(map[string][]string{
+		"SUPERUSER":             {constants.ROLE_ADMIN},
+		constants.ROLE_ADMIN:    {constants.ROLE_WILDCARD},
+		"PLACEMENT_COORDINATOR": {"OFFER_APPROVE"},
+	})
+
+	roleSet := roleGraph.Expand(NewResolvedRoleSet([]string{"SUPERUSER"}))
+
+	// Every built-in role and every role the implications name, but never the wildcard itself
+	for _, role := range append(append([]string{}, constants.ALL_ROLES...), "SUPERUSER", "PLACEMENT_COORDINATOR", "OFFER_APPROVE") {
+		if !roleSet.Has(role) {
+			t.Errorf("Expand() is missing %q", role)
+		}
+	}
+	if roleSet.Has(constants.ROLE_WILDCARD) {
+		t.Errorf("Expand() contains the wildcard")
+	}
+	if roleSet.Has("SOMETHING_ELSE") {
+		t.Errorf("Expand() contains a role no one knows of")
+	}
+}
+
+func TestDefaultRoleGraph(t *testing.T) {
+	roleGraph := NewRoleGraph(constants.DEFAULT_ROLE_IMPLICATIONS)
+
+	tests := []struct {
+		name    string
+		role    string
+		implied string
+		want    bool
+	}{
+		{"ADMIN implies every role", constants.ROLE_ADMIN, constants.ROLE_STUDENT_VERIFY, true},
+		{"edit implies read", constants.ROLE_GROUP_EDIT, constants.ROLE_GROUP_READ, true},
+		{"read does not imply edit", constants.ROLE_GROUP_READ, constants.ROLE_GROUP_EDIT, false},
+		{"TPR implies nothing", constants.ROLE_TPR, constants.ROLE_ADMIN, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := roleGraph.Expand(NewResolvedRoleSet([]string{test.role})).Has(test.implied); got != test.want {
+				t.Errorf("Expand(%s).Has(%s) = %v, want %v", test.role, test.implied, got, test.want)
+			}
+		})
+	}
+}